@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/authors"
+	"gosuda.org/website/internal/jsonld"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
+)
+
+// defaultAvatar is shown for authors without a registered avatar.
+const defaultAvatar = "/assets/apple-touch-icon.png"
+
+// authorURL returns the URL of the author page of handle in lang.
+func authorURL(handle string, lang types.Lang) string {
+	return langURL(lang, "/authors/"+handle+"/")
+}
+
+// postAuthor returns the registered profile of the author named in front matter.
+func postAuthor(gc *GenerationContext, name string) (*authors.Author, bool) {
+	return gc.Authors.Lookup(name)
+}
+
+// postBylines returns the authors credited on post in lang. Registered authors
+// are shown with their display name and linked to their author page.
+func postBylines(gc *GenerationContext, post *types.Post, lang types.Lang) []*view.Byline {
+	var bylines []*view.Byline
+	for _, name := range post.Main.Metadata.AuthorNames() {
+		byline := &view.Byline{Name: name, Avatar: defaultAvatar}
+		if a, ok := postAuthor(gc, name); ok {
+			byline.Name = a.Name
+			byline.URL = authorURL(a.Handle, lang)
+			if avatar := a.AvatarURL(); avatar != "" {
+				byline.Avatar = avatar
+			}
+		}
+		bylines = append(bylines, byline)
+	}
+	return bylines
+}
+
+// bylineNames returns the names of bylines joined for plain-text contexts.
+func bylineNames(bylines []*view.Byline) string {
+	names := make([]string, len(bylines))
+	for i, byline := range bylines {
+		names[i] = byline.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// authorPerson returns the schema.org person of the author named in front matter.
+func authorPerson(gc *GenerationContext, name string, lang types.Lang) *jsonld.Person {
+	a, ok := postAuthor(gc, name)
+	if !ok {
+		return &jsonld.Person{Name: name}
+	}
+	p := &jsonld.Person{
+		Name:   a.Name,
+		URL:    authorURL(a.Handle, lang),
+		SameAs: a.SameAs(),
+	}
+	if avatar := a.AvatarURL(); avatar != "" {
+		p.Image = absoluteURL(avatar)
+	}
+	return p
+}
+
+// absoluteURL resolves a site-relative URL against cfg.BaseURL.
+func absoluteURL(u string) string {
+	if len(u) > 0 && u[0] == '/' {
+		return cfg.BaseURL + u
+	}
+	return u
+}
+
+// checkAuthors logs a warning for every author named in front matter that is not registered.
+func checkAuthors(gc *GenerationContext) {
+	unknown := make(map[string][]string)
+	for _, post := range gc.DataStore.Posts {
+		for _, name := range post.Main.Metadata.AuthorNames() {
+			if _, ok := postAuthor(gc, name); !ok {
+				unknown[name] = append(unknown[name], post.FilePath)
+			}
+		}
+	}
+
+	for name, paths := range unknown {
+		sort.Strings(paths)
+		log.Warn().Str("author", name).Strs("paths", paths).Msgf("author %q is not registered in %s", name, authorsFile)
+	}
+}
+
+func generateAuthorPages(gc *GenerationContext) error {
+	log.Debug().Msg("start generating author pages")
+
+	checkAuthors(gc)
+
+	posts := make(map[string][]*types.Post)
+	for _, post := range sortedPosts(gc.DataStore) {
+		if post.Main.Metadata.Hidden {
+			continue
+		}
+		for _, name := range post.Main.Metadata.AuthorNames() {
+			if a, ok := postAuthor(gc, name); ok {
+				posts[a.Handle] = append(posts[a.Handle], post)
+			}
+		}
+	}
+
+	for _, a := range gc.Authors.All() {
+		sort.SliceStable(posts[a.Handle], func(i, j int) bool {
+			return posts[a.Handle][i].Main.Metadata.Date.After(posts[a.Handle][j].Main.Metadata.Date)
+		})
+
+		for _, lang := range types.SupportedLanguages {
+			err := generateAuthorPage(gc, a, posts[a.Handle], lang)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Debug().Msg("done generating author pages")
+	return nil
+}
+
+func generateAuthorPage(gc *GenerationContext, a *authors.Author, posts []*types.Post, lang types.Lang) error {
+	profile := &view.AuthorProfile{
+		Handle: a.Handle,
+		Name:   a.Name,
+		Avatar: a.AvatarURL(),
+		Bio:    a.LocalizedBio(lang),
+	}
+	if profile.Avatar == "" {
+		profile.Avatar = defaultAvatar
+	}
+
+	keys := make([]string, 0, len(a.Links))
+	for k := range a.Links {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		profile.Links = append(profile.Links, view.KV{Key: k, Value: a.Links[k]})
+	}
+
+	var previews []*view.BlogPostPreview
+	var listed []*types.Post
+	for _, post := range posts {
+		if preview, ok := postPreview(gc, post, lang); ok {
+			previews = append(previews, preview)
+			listed = append(listed, post)
+		}
+	}
+
+	path := "/authors/" + a.Handle + "/"
+	meta := &view.Metadata{
+		Language:    lang,
+		Title:       cfg.Site.Name + " | " + a.Name,
+		Description: profile.Bio,
+		Author:      a.Name,
+		Image:       absoluteURL(profile.Avatar),
+		URL:         langURL(lang, path),
+		Canonical:   langURL(lang, path),
+		BaseURL:     cfg.BaseURL,
+		CreatedAt:   cfg.Site.CreatedAt,
+		UpdatedAt:   lastUpdate(listed),
+		Type:        "profile",
+		SiteName:    cfg.Site.Name,
+		Locale:      types.Locale(lang),
+	}
+	if meta.Description == "" {
+		meta.Description = view.Message(lang, "author_description", "{author}", a.Name, "{site}", cfg.Site.Name)
+	}
+
+	alt := &view.Alternate{Default: langURL(types.LangEnglish, path)}
+	for _, other := range types.SupportedLanguages {
+		alt.Versions = append(alt.Versions, view.KV{Key: other, Value: langURL(other, path)})
+	}
+	meta.Alternate = alt
+
+	var b bytes.Buffer
+	err := view.AuthorPage(meta, profile, previews).Render(context.Background(), &b)
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{filepath.Join(cfg.DistDir, lang, "authors", a.Handle)}
+	if lang == types.LangEnglish {
+		dirs = append(dirs, filepath.Join(cfg.DistDir, "authors", a.Handle))
+	}
+	for _, dir := range dirs {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(dir, "index.html"), b.Bytes(), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
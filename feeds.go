@@ -1,18 +1,19 @@
 package main
 
 import (
-	"bytes"
-	"context"
 	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gorilla/feeds"
 	"github.com/rs/zerolog/log"
 	"github.com/zeebo/blake3"
 	"gosuda.org/website/internal/types"
-	"gosuda.org/website/view"
 )
 
 func langFeedID(id string, lang types.Lang) string {
@@ -21,143 +22,398 @@ func langFeedID(id string, lang types.Lang) string {
 	return hex.EncodeToString(buf[:])
 }
 
-func createFeedItem(post *types.Post, doc *types.Document, link string) *feeds.Item {
-	return &feeds.Item{
-		Id:          langFeedID(post.ID, doc.Metadata.Language),
-		Title:       doc.Metadata.Title,
-		Link:        &feeds.Link{Href: link},
-		Author:      &feeds.Author{Name: doc.Metadata.Author},
-		Description: doc.Metadata.Description,
-		Created:     post.CreatedAt.UTC(),
-		Updated:     post.UpdatedAt.UTC(),
-	}
+// feedEntry is a feed item together with the fields gorilla/feeds cannot express.
+type feedEntry struct {
+	*feeds.Item
+	Language   types.Lang
+	Categories []string
+	Date       time.Time
+	// Authors are all authors of the post; Item.Author is the first of them.
+	Authors []*feedAuthor
+	Stats   types.TextStats
 }
 
-func generateGlobalFeed(gc *GenerationContext) error {
-	log.Debug().Msg("start generating global RSS feed")
-	globalFeed := &feeds.Feed{
-		Title:       "Gosuda Blog",
-		Link:        &feeds.Link{Href: "https://gosuda.org/"},
-		Description: "Gosuda: A blog about software development, and other topics.",
-		Author:      &feeds.Author{Name: "Gosuda", Email: "webmaster@gosuda.org"},
-		Created:     time.Now().UTC(),
+// feedAuthor is an author of a feed entry. URL and Avatar are set for registered authors.
+type feedAuthor struct {
+	Name   string
+	Email  string
+	URL    string
+	Avatar string
+}
+
+func createFeedItem(gc *GenerationContext, post *types.Post, doc *types.Document, link string) *feedEntry {
+	categories := make([]string, 0, len(doc.Metadata.Tags)+1)
+	if section := postSection(post); section != "" {
+		categories = append(categories, section)
 	}
+	categories = append(categories, doc.Metadata.Tags...)
 
-	for _, post := range gc.DataStore.Posts {
-		doc := post.Main
-		if doc.Metadata.Language != "en" {
-			enDoc, ok := post.Translated["en"]
-			if !ok {
-				continue
+	entry := &feedEntry{
+		Item: &feeds.Item{
+			Id:          langFeedID(post.ID, doc.Metadata.Language),
+			Title:       doc.Metadata.Title,
+			Link:        &feeds.Link{Href: link},
+			Description: doc.Metadata.Description,
+			Content:     absoluteHTML(admonitionTitles(doc.HTML, doc.Metadata.Language)),
+			Created:     post.CreatedAt.UTC(),
+			Updated:     post.UpdatedAt.UTC(),
+		},
+		Language:   doc.Metadata.Language,
+		Categories: categories,
+		Date:       doc.Metadata.Date,
+		Stats:      doc.Stats,
+	}
+
+	for _, name := range post.Main.Metadata.AuthorNames() {
+		author := &feedAuthor{Name: name}
+		if a, ok := postAuthor(gc, name); ok {
+			author.Name = a.Name
+			author.Email = a.Email
+			author.URL = authorURL(a.Handle, doc.Metadata.Language)
+			if avatar := a.AvatarURL(); avatar != "" {
+				author.Avatar = absoluteURL(avatar)
 			}
-			doc = enDoc
 		}
-		link := baseURL + post.Path
-
-		globalFeed.Items = append(globalFeed.Items, createFeedItem(post, doc, link))
+		entry.Authors = append(entry.Authors, author)
 	}
-
-	globalFeed.Items = append(globalFeed.Items, &feeds.Item{
-		Id:          langFeedID("home", types.LangEnglish),
-		Title:       "GoSuda | Home",
-		Link:        &feeds.Link{Href: baseURL + "/"},
-		Author:      &feeds.Author{Name: "GoSuda"},
-		Description: "GoSuda is an industry-leading open source working group enabling developers to easily build, prototype, and deploy applications. Our comprehensive suite of tools and frameworks empowers developers to create robust, scalable solutions across various domains.",
-		Created:     time.Date(2024, 10, 07, 0, 0, 0, 0, time.UTC),
-		Updated:     time.Now().UTC(),
-	})
-
-	rss, err := globalFeed.ToRss()
-	if err != nil {
-		return err
+	if len(entry.Authors) > 0 {
+		entry.Author = &feeds.Author{Name: entry.Authors[0].Name, Email: entry.Authors[0].Email}
 	}
+	return entry
+}
 
-	sitemap, err := encodeSiteMapXML(globalFeed)
-	if err != nil {
-		return err
-	}
+// absoluteHTML rewrites site-relative links in rendered HTML to absolute URLs,
+// so that feed readers resolve images and links against the site.
+func absoluteHTML(html string) string {
+	html = strings.ReplaceAll(html, ` src="/`, ` src="`+cfg.BaseURL+`/`)
+	html = strings.ReplaceAll(html, ` href="/`, ` href="`+cfg.BaseURL+`/`)
+	html = strings.ReplaceAll(html, `="`+cfg.BaseURL+`//`, `="//`)
+	return html
+}
 
-	err = os.WriteFile(filepath.Join(distDir, "feed.rss"), []byte(rss), 0644)
-	if err != nil {
-		return err
+func generateGlobalFeed(gc *GenerationContext) error {
+	log.Debug().Msg("start generating global feeds")
+	text := cfg.Site.Localized(types.LangEnglish)
+	globalFeed := &feeds.Feed{
+		Title:       text.FeedTitle,
+		Link:        &feeds.Link{Href: cfg.BaseURL + "/"},
+		Description: text.FeedDescription,
+		Author:      &feeds.Author{Name: cfg.Site.Name, Email: cfg.Site.Email},
+		Created:     cfg.Site.CreatedAt.UTC(),
 	}
 
-	err = os.WriteFile(filepath.Join(distDir, "en", "feed.rss"), []byte(rss), 0644)
-	if err != nil {
-		return err
-	}
+	entries := collectFeedEntries(gc, types.LangEnglish)
 
-	err = os.WriteFile(filepath.Join(distDir, "sitemap.xml"), []byte(sitemap), 0644)
+	err := writeFeeds(globalFeed, types.LangEnglish, entries, cfg.DistDir, filepath.Join(cfg.DistDir, "en"))
 	if err != nil {
 		return err
 	}
 
-	log.Debug().Msg("done generating global RSS feed")
+	log.Debug().Msg("done generating global feeds")
 	return nil
 }
 
 func generateLocalFeed(gc *GenerationContext, lang types.Lang) error {
-	log.Debug().Str("lang", string(lang)).Msg("start generating local RSS feed")
+	log.Debug().Str("lang", string(lang)).Msg("start generating local feeds")
 
+	text := cfg.Site.Localized(lang)
 	feed := &feeds.Feed{
-		Title:       "GoSuda Blog" + " - " + types.FullLangName(lang),
-		Link:        &feeds.Link{Href: baseURL + "/" + string(lang) + "/"},
-		Description: "Gosuda: A blog about software development, and other topics.",
-		Author:      &feeds.Author{Name: "Gosuda", Email: "webmaster@gosuda.org"},
-		Created:     time.Now().UTC(),
+		Title:       text.FeedTitle + " - " + types.FullLangName(lang),
+		Link:        &feeds.Link{Href: cfg.BaseURL + "/" + string(lang) + "/"},
+		Description: text.FeedDescription,
+		Author:      &feeds.Author{Name: cfg.Site.Name, Email: cfg.Site.Email},
+		Created:     cfg.Site.CreatedAt.UTC(),
 	}
 
+	entries := collectFeedEntries(gc, lang)
+
+	err := writeFeeds(feed, lang, entries, filepath.Join(cfg.DistDir, string(lang)))
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("lang", string(lang)).Msg("done generating local feeds")
+	return nil
+}
+
+// collectFeedEntries returns the feed entries of every post available in lang, newest first.
+func collectFeedEntries(gc *GenerationContext, lang types.Lang) []*feedEntry {
+	var entries []*feedEntry
 	for _, post := range gc.DataStore.Posts {
+		if post.Main.Metadata.Hidden {
+			continue
+		}
 		doc, ok := post.Translated[string(lang)]
 		if !ok {
 			continue
 		}
-		link := baseURL + "/" + string(lang) + post.Path
-
-		feed.Items = append(feed.Items, createFeedItem(post, doc, link))
+		entries = append(entries, createFeedItem(gc, post, doc, langURL(lang, post.Path)))
 	}
 
-	feed.Items = append(feed.Items, &feeds.Item{
-		Id:          langFeedID("home", lang),
-		Title:       "GoSuda | Home",
-		Link:        &feeds.Link{Href: baseURL + "/" + string(lang) + "/"},
-		Author:      &feeds.Author{Name: "GoSuda"},
-		Description: "GoSuda is an industry-leading open source working group enabling developers to easily build, prototype, and deploy applications. Our comprehensive suite of tools and frameworks empowers developers to create robust, scalable solutions across various domains.",
-		Created:     time.Date(2024, 10, 07, 0, 0, 0, 0, time.UTC),
-		Updated:     time.Now().UTC(),
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.After(entries[j].Date)
+		}
+		return entries[i].Id < entries[j].Id
 	})
+	return entries
+}
 
-	rss, err := feed.ToRss()
-	if err != nil {
-		return err
+// writeFeeds writes feed.rss, feed.atom and feed.json for the newest entries into every dir.
+func writeFeeds(feed *feeds.Feed, lang types.Lang, entries []*feedEntry, dirs ...string) error {
+	if len(entries) > cfg.Site.FeedItems {
+		entries = entries[:cfg.Site.FeedItems]
 	}
 
-	sitemap, err := encodeSiteMapXML(feed)
+	feed.Items = make([]*feeds.Item, 0, len(entries))
+	for _, entry := range entries {
+		feed.Items = append(feed.Items, entry.Item)
+		if entry.Updated.After(feed.Updated) {
+			feed.Updated = entry.Updated
+		}
+	}
+
+	rss, err := encodeRSS(feed, lang, entries)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(filepath.Join(distDir, string(lang), "feed.rss"), []byte(rss), 0644)
+	atom, err := encodeAtom(feed, lang, entries)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(filepath.Join(distDir, string(lang), "sitemap.xml"), sitemap, 0644)
+	jsonFeed, err := encodeJSONFeed(feed, lang, entries)
 	if err != nil {
 		return err
 	}
 
-	log.Debug().Str("lang", string(lang)).Msg("done generating local RSS feed")
+	for _, dir := range dirs {
+		err = os.WriteFile(filepath.Join(dir, "feed.rss"), rss, 0644)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(dir, "feed.atom"), atom, 0644)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(dir, "feed.json"), jsonFeed, 0644)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+// rssFeed is an RSS 2.0 feed whose items have every category of their post, where
+// feeds.RssItem has a single one.
+type rssFeed struct {
+	XMLName          xml.Name `xml:"rss"`
+	Version          string   `xml:"version,attr"`
+	ContentNamespace string   `xml:"xmlns:content,attr"`
+	Channel          *rssChannel
+}
+
+type rssChannel struct {
+	*feeds.RssFeed
+	Items []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	*feeds.RssItem
+	Categories []string `xml:"category"`
+}
+
+func encodeRSS(feed *feeds.Feed, lang types.Lang, entries []*feedEntry) ([]byte, error) {
+	rss := (&feeds.Rss{Feed: feed}).RssFeed()
+	rss.Language = lang
+	channel := &rssChannel{RssFeed: rss}
+	for i, item := range rss.Items {
+		// RSS 2.0 allows a single author, given as an email address followed by the name.
+		if authors := entries[i].Authors; len(authors) > 0 {
+			names := make([]string, len(authors))
+			for j, author := range authors {
+				names[j] = author.Name
+			}
+			item.Author = strings.Join(names, ", ")
+			if authors[0].Email != "" {
+				item.Author = authors[0].Email + " (" + item.Author + ")"
+			}
+		}
+		channel.Items = append(channel.Items, &rssItem{RssItem: item, Categories: entries[i].Categories})
+	}
+
+	data, err := xml.MarshalIndent(&rssFeed{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		Channel:          channel,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// jsonFeed extends a JSON Feed with the items below.
+type jsonFeed struct {
+	*feeds.JSONFeed
+	Items []*jsonFeedItem `json:"items,omitempty"`
+}
+
+// jsonFeedItem extends a JSON Feed item with the size of the post, as a JSON Feed extension.
+type jsonFeedItem struct {
+	*feeds.JSONItem
+	Reading *jsonFeedReading `json:"_reading,omitempty"`
+}
+
+type jsonFeedReading struct {
+	Words      int `json:"words"`
+	Characters int `json:"characters"`
+	Minutes    int `json:"minutes"`
+}
+
+func encodeJSONFeed(feed *feeds.Feed, lang types.Lang, entries []*feedEntry) ([]byte, error) {
+	jf := (&feeds.JSON{Feed: feed}).JSONFeed()
+	jf.Language = lang
+	jf.FeedUrl = langURL(lang, "/feed.json")
+	jf.Icon = cfg.BaseURL + "/assets/android-chrome-512x512.png"
+	jf.Favicon = cfg.BaseURL + "/assets/favicon-32x32.png"
+	jf.Author = nil
+
+	out := &jsonFeed{JSONFeed: jf}
+	for i, item := range jf.Items {
+		item.Tags = entries[i].Categories
+		item.Authors = nil
+		for _, author := range entries[i].Authors {
+			item.Authors = append(item.Authors, &feeds.JSONAuthor{
+				Name:   author.Name,
+				Url:    author.URL,
+				Avatar: author.Avatar,
+			})
+		}
+		// JSON Feed 1.1 deprecates the singular author field.
+		item.Author = nil
+
+		extended := &jsonFeedItem{JSONItem: item}
+		if stats := entries[i].Stats; stats.Words > 0 {
+			extended.Reading = &jsonFeedReading{
+				Words:      stats.Words,
+				Characters: stats.Characters,
+				Minutes:    stats.ReadingMinutes,
+			}
+		}
+		out.Items = append(out.Items, extended)
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeed is an Atom 1.0 feed. It is encoded directly instead of through gorilla/feeds,
+// which cannot express xml:lang or multiple categories.
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"feed"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Lang     string       `xml:"xml:lang,attr,omitempty"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Icon     string       `xml:"icon,omitempty"`
+	Links    []atomLink   `xml:"link"`
+	Author   *atomPerson  `xml:"author,omitempty"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Lang       string         `xml:"xml:lang,attr,omitempty"`
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	URI   string `xml:"uri,omitempty"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func encodeAtom(feed *feeds.Feed, lang types.Lang, entries []*feedEntry) ([]byte, error) {
+	af := &atomFeed{
+		Xmlns:    atomNamespace,
+		Lang:     lang,
+		ID:       feed.Link.Href,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.Updated.Format(time.RFC3339),
+		Icon:     cfg.BaseURL + "/assets/favicon-32x32.png",
+		Links: []atomLink{
+			{Href: feed.Link.Href, Rel: "alternate", Type: "text/html"},
+			{Href: langURL(lang, "/feed.atom"), Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	if feed.Updated.IsZero() {
+		af.Updated = feed.Created.Format(time.RFC3339)
+	}
+	if feed.Author != nil {
+		af.Author = &atomPerson{Name: feed.Author.Name, Email: feed.Author.Email}
+	}
+
+	for _, entry := range entries {
+		ae := &atomEntry{
+			Lang:      entry.Language,
+			ID:        "tag:" + cfg.Host() + "," + cfg.Site.CreatedAt.Format("2006") + ":" + entry.Id,
+			Title:     entry.Title,
+			Updated:   entry.Updated.Format(time.RFC3339),
+			Published: entry.Created.Format(time.RFC3339),
+			Links:     []atomLink{{Href: entry.Link.Href, Rel: "alternate", Type: "text/html"}},
+		}
+		for _, author := range entry.Authors {
+			ae.Authors = append(ae.Authors, atomPerson{
+				Name:  author.Name,
+				URI:   author.URL,
+				Email: author.Email,
+			})
+		}
+		for _, category := range entry.Categories {
+			ae.Categories = append(ae.Categories, atomCategory{Term: category})
+		}
+		if entry.Description != "" {
+			ae.Summary = &atomText{Type: "text", Body: entry.Description}
+		}
+		if entry.Content != "" {
+			ae.Content = &atomText{Type: "html", Body: entry.Content}
+		}
+		af.Entries = append(af.Entries, ae)
+	}
 
-func encodeSiteMapXML(feed *feeds.Feed) ([]byte, error) {
-	var b bytes.Buffer
-	err := view.Sitemap(feed).Render(context.Background(), &b)
+	data, err := xml.MarshalIndent(af, "", "  ")
 	if err != nil {
 		return nil, err
 	}
-	return append([]byte(xmlHeader), b.Bytes()...), nil
+	return append([]byte(xml.Header), data...), nil
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"gosuda.org/website/internal/authors"
+	"gosuda.org/website/internal/config"
+	"gosuda.org/website/internal/types"
+)
+
+// writeTestFeeds writes the feeds of three posts, with a limit of two items, and
+// returns the directory they are written to.
+func writeTestFeeds(t *testing.T) string {
+	t.Helper()
+	prev := cfg
+	t.Cleanup(func() { cfg = prev })
+	cfg = &config.Config{
+		BaseURL: "https://gosuda.org",
+		RootDir: "root",
+		Site: config.Site{
+			Name:      "GoSuda",
+			Email:     "contact@gosuda.org",
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			FeedItems: 2,
+		},
+	}
+
+	registry, err := authors.New([]*authors.Author{{
+		Handle: "lemonmint",
+		Name:   "Lemon Mint",
+		Email:  "lemon@gosuda.org",
+		Links:  map[string]string{"github": "https://github.com/lemon-mint"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc := &GenerationContext{Authors: registry}
+
+	var entries []*feedEntry
+	for i, id := range []string{"first", "second", "third"} {
+		date := time.Date(2025, 3, 1+i, 0, 0, 0, 0, time.UTC)
+		doc := &types.Document{
+			HTML: `<p><a href="/blog/posts/` + id + `">` + id + `</a></p>`,
+			Metadata: types.Metadata{
+				ID:          id,
+				Title:       "Post " + id,
+				Description: "The " + id + " post",
+				Language:    types.LangEnglish,
+				Author:      "lemonmint",
+				Authors:     []string{"Guest Writer"},
+				Tags:        []string{"go", "web"},
+				Date:        date,
+			},
+			Stats: types.TextStats{Words: 120, Characters: 600, ReadingMinutes: 1},
+		}
+		post := &types.Post{
+			ID:        id,
+			Path:      "/blog/posts/" + id,
+			FilePath:  filepath.Join("root", "blog", id+".md"),
+			Main:      doc,
+			CreatedAt: date,
+			UpdatedAt: date.Add(time.Hour),
+		}
+		entries = append(entries, createFeedItem(gc, post, doc, langURL(types.LangEnglish, post.Path)))
+	}
+	slices.Reverse(entries)
+
+	dir := t.TempDir()
+	feed := &feeds.Feed{
+		Title:   "GoSuda Blog",
+		Link:    &feeds.Link{Href: cfg.BaseURL + "/"},
+		Author:  &feeds.Author{Name: cfg.Site.Name, Email: cfg.Site.Email},
+		Created: cfg.Site.CreatedAt,
+	}
+	err = writeFeeds(feed, types.LangEnglish, entries, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readTestFeed(t *testing.T, dir, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+var testCategories = []string{"blog", "go", "web"}
+
+func TestRSSFeed(t *testing.T) {
+	dir := writeTestFeeds(t)
+
+	var rss struct {
+		Channel struct {
+			Language string `xml:"language"`
+			Items    []struct {
+				Title      string   `xml:"title"`
+				Author     string   `xml:"author"`
+				Categories []string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	err := xml.Unmarshal(readTestFeed(t, dir, "feed.rss"), &rss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rss.Channel.Language != "en" {
+		t.Errorf("expected language en, got %q", rss.Channel.Language)
+	}
+	if len(rss.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(rss.Channel.Items))
+	}
+	item := rss.Channel.Items[0]
+	if item.Title != "Post third" {
+		t.Errorf("expected the newest post first, got %q", item.Title)
+	}
+	if want := "lemon@gosuda.org (Lemon Mint, Guest Writer)"; item.Author != want {
+		t.Errorf("expected author %q, got %q", want, item.Author)
+	}
+	if !slices.Equal(item.Categories, testCategories) {
+		t.Errorf("expected categories %v, got %v", testCategories, item.Categories)
+	}
+}
+
+func TestAtomFeed(t *testing.T) {
+	dir := writeTestFeeds(t)
+
+	var atom struct {
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Authors []struct {
+				Name string `xml:"name"`
+				URI  string `xml:"uri"`
+			} `xml:"author"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	err := xml.Unmarshal(readTestFeed(t, dir, "feed.atom"), &atom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "2025-03-03T01:00:00Z"; atom.Updated != want {
+		t.Errorf("expected the feed updated at %s, got %s", want, atom.Updated)
+	}
+	if len(atom.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(atom.Entries))
+	}
+	entry := atom.Entries[0]
+	if want := "tag:gosuda.org,2024:" + langFeedID("third", types.LangEnglish); entry.ID != want {
+		t.Errorf("expected id %s, got %s", want, entry.ID)
+	}
+	if len(entry.Authors) != 2 || entry.Authors[0].URI != "https://gosuda.org/authors/lemonmint/" || entry.Authors[1].Name != "Guest Writer" {
+		t.Errorf("unexpected authors %+v", entry.Authors)
+	}
+	var terms []string
+	for _, c := range entry.Categories {
+		terms = append(terms, c.Term)
+	}
+	if !slices.Equal(terms, testCategories) {
+		t.Errorf("expected categories %v, got %v", testCategories, terms)
+	}
+	if want := `<p><a href="https://gosuda.org/blog/posts/third">third</a></p>`; entry.Content != want {
+		t.Errorf("expected content %q, got %q", want, entry.Content)
+	}
+}
+
+func TestJSONFeed(t *testing.T) {
+	dir := writeTestFeeds(t)
+
+	var jf struct {
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			Tags    []string            `json:"tags"`
+			Author  json.RawMessage     `json:"author"`
+			Authors []*feeds.JSONAuthor `json:"authors"`
+			Reading *jsonFeedReading    `json:"_reading"`
+		} `json:"items"`
+	}
+	err := json.Unmarshal(readTestFeed(t, dir, "feed.json"), &jf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jf.FeedURL != "https://gosuda.org/feed.json" {
+		t.Errorf("unexpected feed_url %q", jf.FeedURL)
+	}
+	if len(jf.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(jf.Items))
+	}
+	item := jf.Items[0]
+	if !slices.Equal(item.Tags, testCategories) {
+		t.Errorf("expected tags %v, got %v", testCategories, item.Tags)
+	}
+	if item.Author != nil {
+		t.Errorf("expected no deprecated author, got %s", item.Author)
+	}
+	if len(item.Authors) != 2 || item.Authors[0].Avatar != "https://github.com/lemon-mint.png" || item.Authors[1].Url != "" {
+		t.Errorf("unexpected authors %+v", item.Authors)
+	}
+	if item.Reading == nil || item.Reading.Words != 120 || item.Reading.Minutes != 1 {
+		t.Errorf("unexpected reading %+v", item.Reading)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"image/png"
 	"os"
 	"path/filepath"
 	"sort"
@@ -20,10 +19,10 @@ import (
 func generate(gc *GenerationContext) error {
 	log.Debug().Msg("start generating website")
 
-	distInfo, err := os.Stat(distDir)
+	distInfo, err := os.Stat(cfg.DistDir)
 	if err == nil && distInfo.IsDir() {
 		log.Debug().Msg("deleting dist directory")
-		err := os.RemoveAll(distDir)
+		err := os.RemoveAll(cfg.DistDir)
 		if err != nil {
 			return err
 		}
@@ -31,18 +30,25 @@ func generate(gc *GenerationContext) error {
 	}
 
 	log.Debug().Msg("copying static files")
-	err = copyDir(publicDir, distDir)
+	err = copyDir(publicDir, cfg.DistDir)
 	if err != nil {
 		return err
 	}
 	log.Debug().Msg("copied static files")
 
+	err = generateLanguageFiles()
+	if err != nil {
+		return err
+	}
+
 	log.Debug().Msg("creating root file index")
-	list, err := generateFileList(rootDir)
+	list, err := generateFileList(cfg.RootDir)
 	if err != nil {
 		return err
 	}
 
+	prepareImages(gc, list)
+
 	for _, path := range list {
 		log.Debug().Str("path", path).Msgf("processing file %s", path)
 		switch strings.ToLower(filepath.Ext(path)) {
@@ -64,6 +70,12 @@ func generate(gc *GenerationContext) error {
 			delete(gc.DataStore.Posts, id)
 		}
 	}
+	gc.Images.Prune()
+	translateMessages(gc)
+
+	// Drafts and scheduled posts stay in the database, so that they are
+	// translated ahead of time, but are left out of every generated page.
+	gc = publishedContext(gc)
 
 	for _, lang := range types.SupportedLanguages {
 		err = generateIndex(gc, lang)
@@ -76,6 +88,11 @@ func generate(gc *GenerationContext) error {
 		}
 	}
 
+	err = generateOGImages(gc)
+	if err != nil {
+		return err
+	}
+
 	err = generateGlobalFeed(gc)
 	if err != nil {
 		return err
@@ -91,7 +108,39 @@ func generate(gc *GenerationContext) error {
 		}
 	}
 
-	err = minifyDir(distDir)
+	err = generateAuthorPages(gc)
+	if err != nil {
+		return err
+	}
+
+	err = generateSeriesPages(gc)
+	if err != nil {
+		return err
+	}
+
+	err = generatePackagePages(gc)
+	if err != nil {
+		return err
+	}
+
+	err = generateLanguageRedirects(gc)
+	if err != nil {
+		return err
+	}
+
+	err = generateSitemaps(gc)
+	if err != nil {
+		return err
+	}
+
+	for _, lang := range types.SupportedLanguages {
+		err = generateLLMsTxt(gc, lang)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = minifyDir(cfg.DistDir)
 	if err != nil {
 		return err
 	}
@@ -102,14 +151,8 @@ func generate(gc *GenerationContext) error {
 
 func generatePostPages(gc *GenerationContext, lang types.Lang) error {
 	log.Debug().Msg("start generating post pages")
-	postList := make([]*types.Post, 0, len(gc.DataStore.Posts))
-	for _, post := range gc.DataStore.Posts {
-		postList = append(postList, post)
-	}
-
-	sort.Slice(postList, func(i, j int) bool {
-		return postList[i].ID < postList[j].ID
-	})
+	postList := sortedPosts(gc.DataStore)
+	allSeries := collectSeries(gc)
 
 	var b bytes.Buffer
 	ctx := context.Background()
@@ -124,79 +167,73 @@ func generatePostPages(gc *GenerationContext, lang types.Lang) error {
 			}
 		}
 
-		languages := make([]string, 0, len(post.Translated))
-		for lang := range post.Translated {
-			languages = append(languages, lang)
-		}
-		sort.Strings(languages)
+		languages := postLanguages(post)
 
 		path := "/" + lang + post.Path
 
 		log.Debug().Str("path", post.Path).Msgf("generating post page %s", path)
 
-		fp := filepath.Join(distDir, path)
+		fp := filepath.Join(cfg.DistDir, path)
 		err := os.MkdirAll(filepath.Dir(fp), 0755)
 		if err != nil {
 			return err
 		}
 
-		ogImagePath := filepath.Join(distDir, "assets", post.ID+"_"+lang+".png")
-		err = os.MkdirAll(filepath.Dir(ogImagePath), 0755)
-		if err != nil {
-			return err
-		}
+		url := langURL(lang, post.Path)
 
-		url := baseURL + "/" + lang + post.Path
+		bylines := postBylines(gc, post, lang)
 
 		meta := &view.Metadata{
 			Language:    lang,
 			Title:       pm.Title,
 			Description: pm.Description,
-			Author:      pm.Author,
-			Image:       baseURL + "/assets/" + post.ID + "_" + lang + ".png",
+			Author:      bylineNames(bylines),
+			Authors:     bylines,
+			Image:       ogImageURL(post, lang),
 			URL:         url,
-			Canonical:   url,
-			BaseURL:     baseURL,
+			Canonical:   postCanonical(post, lang),
+			BaseURL:     cfg.BaseURL,
 			CreatedAt:   post.CreatedAt,
 			UpdatedAt:   post.UpdatedAt,
+			Keywords:    pm.Tags,
+
+			Type:        "article",
+			SiteName:    cfg.Site.Name,
+			Locale:      types.Locale(lang),
+			ImageWidth:  ogimage.Width,
+			ImageHeight: ogimage.Height,
+			ImageAlt:    pm.Title,
+			ImageWebP:   ogImageWebPURL(post, lang),
+			TwitterCard: "summary_large_image",
 		}
 
-		alt := &view.Alternate{}
-		for _, lang := range languages {
-			if lang == types.LangEnglish {
-				alt.Versions = append(alt.Versions, view.KV{
-					Key:   lang,
-					Value: baseURL + post.Path,
-				})
-				continue
-			}
+		alt := &view.Alternate{Default: postDefaultURL(post)}
+		for _, altLang := range languages {
 			alt.Versions = append(alt.Versions, view.KV{
-				Key:   lang,
-				Value: baseURL + "/" + lang + post.Path,
+				Key:   altLang,
+				Value: langURL(altLang, post.Path),
 			})
+			if altLang != lang {
+				meta.LocaleAlternates = append(meta.LocaleAlternates, types.Locale(altLang))
+			}
 		}
 		meta.Alternate = alt
-
-		if lang == types.LangEnglish {
-			meta.URL = baseURL + post.Path
-			meta.Canonical = meta.URL
-		}
-
-		if post.Main.Metadata.Canonical != "" {
-			meta.Canonical = post.Main.Metadata.Canonical
-		}
-
-		if post.Main.Metadata.LangCanonical != nil &&
-			post.Main.Metadata.LangCanonical[lang] != "" {
-			meta.Canonical = post.Main.Metadata.LangCanonical[lang]
+		meta.Series = seriesNav(allSeries, post, lang)
+		meta.StructuredData, err = postStructuredData(gc, post, lang, post.Translated[lang])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
 		}
 
 		if post.Main.Metadata.GoPackage != "" {
-			meta.GoImport = fmt.Sprintf("%s git %s", post.Main.Metadata.GoPackage, post.Main.Metadata.GoRepoURL)
+			meta.GoImport = goImportMeta(post.Main.Metadata.GoPackage, post.Main.Metadata.GoRepoURL)
+			meta.GoSource = goSourceMeta(post.Main.Metadata.GoPackage, post.Main.Metadata.GoRepoURL)
 		}
 
+		doc := *post.Translated[lang]
+		doc.HTML = admonitionTitles(doc.HTML, lang)
+
 		b.Reset()
-		err = view.PostPage(meta, post.Translated[lang], post).Render(ctx, &b)
+		err = view.PostPage(meta, &doc, post).Render(ctx, &b)
 		if err != nil {
 			return err
 		}
@@ -214,12 +251,12 @@ func generatePostPages(gc *GenerationContext, lang types.Lang) error {
 		}
 
 		if lang == types.LangEnglish {
-			err := os.MkdirAll(filepath.Dir(filepath.Join(distDir, post.Path)), 0755)
+			err := os.MkdirAll(filepath.Dir(filepath.Join(cfg.DistDir, post.Path)), 0755)
 			if err != nil {
 				return err
 			}
 
-			fp = filepath.Join(distDir, post.Path)
+			fp = filepath.Join(cfg.DistDir, post.Path)
 			if strings.HasSuffix(fp, "/") {
 				err = os.WriteFile(fp+"index.html", b.Bytes(), 0644)
 				if err != nil {
@@ -232,26 +269,6 @@ func generatePostPages(gc *GenerationContext, lang types.Lang) error {
 				}
 			}
 		}
-
-		img := ogimage.GenerateImage("GoSuda", pm.Title, pm.Date)
-		f, err := os.Create(ogImagePath)
-		if err != nil {
-			return err
-		}
-
-		err = png.Encode(f, img)
-		if err != nil {
-			err = f.Close()
-			if err != nil {
-				return err
-			}
-			return err
-		}
-
-		err = f.Close()
-		if err != nil {
-			return err
-		}
 	}
 
 	log.Debug().Msg("done generating post pages")
@@ -263,44 +280,59 @@ func generateIndex(gc *GenerationContext, lang types.Lang) error {
 	var b bytes.Buffer
 	ctx := context.Background()
 
-	err := os.MkdirAll(filepath.Join(distDir, lang), 0755)
+	err := os.MkdirAll(filepath.Join(cfg.DistDir, lang), 0755)
 	if err != nil {
 		return err
 	}
 
+	text := cfg.Site.Localized(lang)
 	meta := &view.Metadata{
 		Language:    lang,
-		Title:       "GoSuda | Home",
-		Description: "GoSuda is an industry-leading open source working group enabling developers to easily build, prototype, and deploy applications. Our comprehensive suite of tools and frameworks empowers developers to create robust, scalable solutions across various domains.",
-		Author:      "GoSuda",
-		Image:       baseURL + "/assets/images/ogp_placeholder.png",
-		URL:         baseURL + "/",
-		Canonical:   baseURL + "/",
-		BaseURL:     baseURL,
-		CreatedAt:   time.Date(2024, 10, 07, 0, 0, 0, 0, time.UTC),
-		UpdatedAt:   time.Now().UTC(),
+		Title:       text.Title,
+		Description: text.Description,
+		Author:      cfg.Site.Name,
+		Image:       cfg.BaseURL + "/assets/images/ogp_placeholder.png",
+		URL:         cfg.BaseURL + "/",
+		Canonical:   cfg.BaseURL + "/",
+		BaseURL:     cfg.BaseURL,
+		CreatedAt:   cfg.Site.CreatedAt,
+
+		Type:        "website",
+		SiteName:    cfg.Site.Name,
+		Locale:      types.Locale(lang),
+		ImageWidth:  2300,
+		ImageHeight: 1260,
+		ImageAlt:    cfg.Site.Name,
+		TwitterCard: "summary_large_image",
 	}
 
 	if lang != "en" {
-		meta.URL = baseURL + "/" + lang + "/"
-		meta.Canonical = baseURL + "/" + lang + "/"
+		meta.URL = cfg.BaseURL + "/" + lang + "/"
+		meta.Canonical = cfg.BaseURL + "/" + lang + "/"
 	}
 
-	alt := &view.Alternate{}
+	alt := &view.Alternate{Default: cfg.BaseURL + "/"}
 	for _, lang := range types.SupportedLanguages {
+		if lang != meta.Language {
+			meta.LocaleAlternates = append(meta.LocaleAlternates, types.Locale(lang))
+		}
 		if lang == types.LangEnglish {
 			alt.Versions = append(alt.Versions, view.KV{
 				Key:   lang,
-				Value: baseURL + "/",
+				Value: cfg.BaseURL + "/",
 			})
 			continue
 		}
 		alt.Versions = append(alt.Versions, view.KV{
 			Key:   lang,
-			Value: baseURL + "/" + lang + "/",
+			Value: cfg.BaseURL + "/" + lang + "/",
 		})
 	}
 	meta.Alternate = alt
+	meta.StructuredData, err = indexStructuredData(lang)
+	if err != nil {
+		return err
+	}
 
 	var posts []*types.Post
 	for _, post := range gc.DataStore.Posts {
@@ -314,31 +346,14 @@ func generateIndex(gc *GenerationContext, lang types.Lang) error {
 	})
 
 	var previews []*view.BlogPostPreview
+	var listed []*types.Post
 	for _, post := range posts {
-		pm := post.Main.Metadata
-		if lang != pm.Language {
-			if _, ok := post.Translated[lang]; ok {
-				pm = post.Translated[lang].Metadata
-			} else {
-				continue
-			}
-		}
-
-		postPath := post.Path
-
-		if lang != "en" {
-			postPath = "/" + lang + post.Path
+		if preview, ok := postPreview(gc, post, lang); ok {
+			previews = append(previews, preview)
+			listed = append(listed, post)
 		}
-
-		previews = append(previews, &view.BlogPostPreview{
-			Title:       pm.Title,
-			Author:      pm.Author,
-			Description: pm.Description,
-			Date:        pm.Date,
-			URL:         postPath,
-			Path:        post.Path,
-		})
 	}
+	meta.UpdatedAt = lastUpdate(listed)
 
 	var featuredPosts []view.FeaturedPost
 
@@ -347,13 +362,13 @@ func generateIndex(gc *GenerationContext, lang types.Lang) error {
 		return err
 	}
 
-	err = os.WriteFile(filepath.Join(distDir, lang, "index.html"), b.Bytes(), 0644)
+	err = os.WriteFile(filepath.Join(cfg.DistDir, lang, "index.html"), b.Bytes(), 0644)
 	if err != nil {
 		return err
 	}
 
 	if lang == "en" {
-		err = os.WriteFile(filepath.Join(distDir, "index.html"), b.Bytes(), 0644)
+		err = os.WriteFile(filepath.Join(cfg.DistDir, "index.html"), b.Bytes(), 0644)
 		if err != nil {
 			return err
 		}
@@ -362,3 +377,54 @@ func generateIndex(gc *GenerationContext, lang types.Lang) error {
 	log.Debug().Msg("done generating index")
 	return nil
 }
+
+// publishedContext returns a copy of gc whose data store only holds the posts
+// to publish. Preview builds publish drafts and scheduled posts as well.
+func publishedContext(gc *GenerationContext) *GenerationContext {
+	if gc.Drafts {
+		return gc
+	}
+
+	now := time.Now()
+	published := &DataStore{Posts: make(map[string]*types.Post, len(gc.DataStore.Posts))}
+	for id, post := range gc.DataStore.Posts {
+		if !post.Main.Metadata.Published(now) {
+			log.Info().Str("path", post.FilePath).Bool("draft", post.Main.Metadata.Draft).Time("publish_at", post.Main.Metadata.PublishAt).Msgf("not publishing %s", post.FilePath)
+			continue
+		}
+		published.Posts[id] = post
+	}
+
+	site := *gc
+	site.DataStore = published
+	return &site
+}
+
+// postPreview returns the preview card of post in lang, or false if the post is not available in lang.
+func postPreview(gc *GenerationContext, post *types.Post, lang types.Lang) (*view.BlogPostPreview, bool) {
+	doc := post.Main
+	if lang != doc.Metadata.Language {
+		if _, ok := post.Translated[lang]; ok {
+			doc = post.Translated[lang]
+		} else {
+			return nil, false
+		}
+	}
+	pm := doc.Metadata
+
+	postPath := post.Path
+
+	if lang != "en" {
+		postPath = "/" + lang + post.Path
+	}
+
+	return &view.BlogPostPreview{
+		Title:          pm.Title,
+		Authors:        postBylines(gc, post, lang),
+		Description:    pm.Description,
+		Date:           pm.Date,
+		ReadingMinutes: doc.Stats.ReadingMinutes,
+		URL:            postPath,
+		Path:           post.Path,
+	}, true
+}
@@ -0,0 +1,154 @@
+// Package authors provides the registry of blog authors.
+package authors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Author is a registered author profile.
+type Author struct {
+	// Handle is the unique identifier of the author, used in URLs. It consists of
+	// lowercase letters, digits and hyphens.
+	Handle string `yaml:"handle"`
+	// Name is the display name of the author.
+	Name string `yaml:"name"`
+	// Aliases are other names the author uses in front matter.
+	Aliases []string `yaml:"aliases,omitempty"`
+	// Avatar is the URL of the author's avatar image.
+	Avatar string `yaml:"avatar,omitempty"`
+	// Email is the public contact address of the author.
+	Email string `yaml:"email,omitempty"`
+	// Bio is the author's biography, keyed by language code.
+	Bio map[string]string `yaml:"bio,omitempty"`
+	// Links are the author's profiles on other sites, keyed by site name (e.g. github).
+	Links map[string]string `yaml:"links,omitempty"`
+}
+
+// LocalizedBio returns the biography in lang, falling back to English.
+func (a *Author) LocalizedBio(lang string) string {
+	if bio, ok := a.Bio[lang]; ok {
+		return bio
+	}
+	return a.Bio["en"]
+}
+
+// AvatarURL returns the avatar of the author, derived from the GitHub profile if not set.
+func (a *Author) AvatarURL() string {
+	if a.Avatar != "" {
+		return a.Avatar
+	}
+	if github := a.Links["github"]; github != "" {
+		return strings.TrimSuffix(github, "/") + ".png"
+	}
+	return ""
+}
+
+// SameAs returns the profile links of the author, sorted by site name.
+func (a *Author) SameAs() []string {
+	keys := make([]string, 0, len(a.Links))
+	for k := range a.Links {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	links := make([]string, 0, len(keys))
+	for _, k := range keys {
+		links = append(links, a.Links[k])
+	}
+	return links
+}
+
+var (
+	ErrDuplicateAuthor = errors.New("duplicate author")
+	ErrInvalidAuthor   = errors.New("invalid author")
+)
+
+// handlePattern matches valid handles, which are used as path segments.
+var handlePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// Registry resolves author names from front matter to profiles.
+type Registry struct {
+	authors []*Author
+	index   map[string]*Author
+}
+
+// normalize folds a name for lookups, ignoring case, spaces and punctuation.
+func normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// New creates a registry from a list of authors.
+func New(list []*Author) (*Registry, error) {
+	r := &Registry{index: make(map[string]*Author)}
+	for _, a := range list {
+		if a.Handle == "" || a.Name == "" {
+			return nil, fmt.Errorf("%w: handle and name are required: %+v", ErrInvalidAuthor, a)
+		}
+		if !handlePattern.MatchString(a.Handle) {
+			return nil, fmt.Errorf("%w: handle %q may only contain lowercase letters, digits and hyphens", ErrInvalidAuthor, a.Handle)
+		}
+
+		for _, name := range append([]string{a.Handle, a.Name}, a.Aliases...) {
+			key := normalize(name)
+			if prev, ok := r.index[key]; ok && prev != a {
+				return nil, fmt.Errorf("%w: %q is used by %s and %s", ErrDuplicateAuthor, name, prev.Handle, a.Handle)
+			}
+			r.index[key] = a
+		}
+		r.authors = append(r.authors, a)
+	}
+
+	sort.Slice(r.authors, func(i, j int) bool {
+		return r.authors[i].Handle < r.authors[j].Handle
+	})
+	return r, nil
+}
+
+// Load reads the registry from a YAML file. A missing file yields an empty registry.
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Author
+	err = yaml.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+	return New(list)
+}
+
+// Lookup returns the author registered under a handle, name or alias.
+func (r *Registry) Lookup(name string) (*Author, bool) {
+	if r == nil {
+		return nil, false
+	}
+	a, ok := r.index[normalize(name)]
+	return a, ok
+}
+
+// All returns every registered author, sorted by handle.
+func (r *Registry) All() []*Author {
+	if r == nil {
+		return nil
+	}
+	return r.authors
+}
@@ -0,0 +1,74 @@
+package authors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	r, err := New([]*Author{
+		{Handle: "lemonmint", Name: "Lemon Mint", Links: map[string]string{"github": "https://github.com/lemon-mint"}},
+		{Handle: "yunjinlee", Name: "Yunjin Lee", Aliases: []string{"Lee Yunjin"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		handle string
+		found  bool
+	}{
+		{"lemonmint", "lemonmint", true},
+		{"Lemon Mint", "lemonmint", true},
+		{"LEMON-MINT", "lemonmint", true},
+		{"Lee Yunjin", "yunjinlee", true},
+		{"yunjin lee", "yunjinlee", true},
+		{"unknown", "", false},
+	}
+
+	for _, tc := range testCases {
+		a, ok := r.Lookup(tc.name)
+		if ok != tc.found {
+			t.Errorf("Lookup(%q): expected found=%v, got %v", tc.name, tc.found, ok)
+			continue
+		}
+		if ok && a.Handle != tc.handle {
+			t.Errorf("Lookup(%q): expected %s, got %s", tc.name, tc.handle, a.Handle)
+		}
+	}
+
+	a, _ := r.Lookup("lemonmint")
+	if a.AvatarURL() != "https://github.com/lemon-mint.png" {
+		t.Errorf("expected avatar derived from GitHub, got %s", a.AvatarURL())
+	}
+}
+
+func TestNewRejectsDuplicates(t *testing.T) {
+	_, err := New([]*Author{
+		{Handle: "a", Name: "Same Name"},
+		{Handle: "b", Name: "same-name"},
+	})
+	if !errors.Is(err, ErrDuplicateAuthor) {
+		t.Errorf("expected ErrDuplicateAuthor, got %v", err)
+	}
+}
+
+func TestNewRejectsInvalidHandles(t *testing.T) {
+	for _, handle := range []string{"Lemon", "lemon mint", "../lemon", "lemon_mint", "레몬"} {
+		_, err := New([]*Author{{Handle: handle, Name: "Lemon Mint"}})
+		if !errors.Is(err, ErrInvalidAuthor) {
+			t.Errorf("New with handle %q: expected ErrInvalidAuthor, got %v", handle, err)
+		}
+	}
+}
+
+func TestLocalizedBio(t *testing.T) {
+	a := &Author{Handle: "a", Name: "A", Bio: map[string]string{"en": "Gopher", "ko": "고퍼"}}
+	if bio := a.LocalizedBio("ko"); bio != "고퍼" {
+		t.Errorf("expected Korean bio, got %q", bio)
+	}
+	if bio := a.LocalizedBio("ja"); bio != "Gopher" {
+		t.Errorf("expected English fallback, got %q", bio)
+	}
+}
@@ -0,0 +1,262 @@
+// Package config loads the configuration of the site from a Jsonnet file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-jsonnet"
+	"gosuda.org/website/internal/types"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config is the configuration of the site.
+type Config struct {
+	// BaseURL is the URL the site is served from, without a trailing slash.
+	BaseURL string `json:"base_url"`
+	// RootDir holds the markdown posts.
+	RootDir string `json:"root_dir"`
+	// DistDir receives the generated site.
+	DistDir string `json:"dist_dir"`
+	// DBFile is the database of posts and their translations.
+	DBFile string `json:"db_file"`
+	Site   Site   `json:"site"`
+	LLM    LLM    `json:"llm"`
+}
+
+// Site describes the site in feeds, metadata and structured data.
+type Site struct {
+	Name string `json:"name"`
+	// Email is the contact address of the site in feeds.
+	Email string `json:"email,omitempty"`
+	// Logo is the site-relative URL of the logo of the organization.
+	Logo string `json:"logo,omitempty"`
+	// SameAs are the profiles of the organization on other sites.
+	SameAs []string `json:"same_as,omitempty"`
+	// VanityHost is the host of the vanity import paths of Go packages, which defaults
+	// to the host of BaseURL. It stays the same when staging builds move BaseURL.
+	VanityHost string `json:"vanity_host,omitempty"`
+	// CreatedAt is the creation date of the site, used for pages that are not posts.
+	CreatedAt time.Time `json:"created_at"`
+	// FeedItems is the number of newest posts published in each feed.
+	FeedItems int `json:"feed_items"`
+	// Strings are the texts of the site, keyed by language code. English is required.
+	Strings map[types.Lang]Strings `json:"strings"`
+}
+
+// Strings are the texts of the site in one language.
+type Strings struct {
+	// Title is the title of the home page.
+	Title string `json:"title,omitempty"`
+	// Description is the description of the home page and of the organization.
+	Description string `json:"description,omitempty"`
+	// FeedTitle and FeedDescription describe the feeds of posts.
+	FeedTitle       string `json:"feed_title,omitempty"`
+	FeedDescription string `json:"feed_description,omitempty"`
+	// About introduces the site in llms.txt.
+	About string `json:"about,omitempty"`
+}
+
+// LLM configures the models that translate, evaluate and describe posts. The calls of
+// each task go to the backends of its route in order, falling back to the next backend
+// when one fails.
+type LLM struct {
+	// Backends are the models calls can be routed to, by name.
+	Backends map[string]Backend `json:"backends"`
+	// Routes list the backends of each task in the order they are tried. Tasks without
+	// a route follow the default route.
+	Routes map[string][]string `json:"routes"`
+	// Timeout bounds a single call to a backend, in seconds. Zero does not bound calls.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Backend is a model of a provider.
+type Backend struct {
+	// Provider is aistudio, vertexai, openai or anthropic.
+	Provider string `json:"provider"`
+	// APIKey authenticates to AI Studio, OpenAI and Anthropic.
+	APIKey string `json:"api_key,omitempty"`
+	// BaseURL replaces the endpoint of OpenAI and Anthropic, for compatible APIs.
+	BaseURL string `json:"base_url,omitempty"`
+	// ProjectID and Location select the Vertex AI project.
+	ProjectID   string  `json:"project_id,omitempty"`
+	Location    string  `json:"location,omitempty"`
+	Model       string  `json:"model"`
+	Temperature float32 `json:"temperature"`
+	// MaxOutputTokens bounds the length of a single response.
+	MaxOutputTokens int `json:"max_output_tokens"`
+}
+
+// Tasks are the uses of the LLM, which can each have a route.
+const (
+	TaskDefault = "default"
+	// TaskTitle translates the titles of posts into their paths.
+	TaskTitle       = "title"
+	TaskTranslation = "translation"
+	TaskEvaluation  = "evaluation"
+	TaskDescription = "description"
+)
+
+var tasks = []string{TaskDefault, TaskTitle, TaskTranslation, TaskEvaluation, TaskDescription}
+
+var providers = []string{"aistudio", "vertexai", "openai", "anthropic"}
+
+// Route returns the names of the backends of the task.
+func (l *LLM) Route(task string) []string {
+	if r, ok := l.Routes[task]; ok {
+		return r
+	}
+	return l.Routes[TaskDefault]
+}
+
+// Configured reports whether the credentials of the backend are set. Backends without
+// credentials are left out of their routes, so that a route can list backends that
+// only some environments have.
+func (b *Backend) Configured() bool {
+	if b.Provider == "vertexai" {
+		return b.ProjectID != "" && b.Location != ""
+	}
+	return b.APIKey != ""
+}
+
+// Localized returns the texts of the site in lang, falling back to English for the
+// texts that are not translated.
+func (s *Site) Localized(lang types.Lang) Strings {
+	en := s.Strings[types.LangEnglish]
+	l, ok := s.Strings[lang]
+	if !ok {
+		return en
+	}
+	fallback(&l.Title, en.Title)
+	fallback(&l.Description, en.Description)
+	fallback(&l.FeedTitle, en.FeedTitle)
+	fallback(&l.FeedDescription, en.FeedDescription)
+	fallback(&l.About, en.About)
+	return l
+}
+
+func fallback(s *string, en string) {
+	if *s == "" {
+		*s = en
+	}
+}
+
+// Host returns the host of BaseURL.
+func (c *Config) Host() string {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// Load evaluates the Jsonnet file into a config. The environment is passed to the
+// file as a JSON object in the env external variable.
+func Load(file string, env map[string]string) (*Config, error) {
+	envJSON, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtVar("env", string(envJSON))
+	out, err := vm.EvaluateFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	var c Config
+	dec := json.NewDecoder(strings.NewReader(out))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, file, err)
+	}
+
+	err = c.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, file, err)
+	}
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+		return fmt.Errorf("base_url %q is not the URL of a site root", c.BaseURL)
+	}
+	if c.RootDir == "" || c.DistDir == "" || c.DBFile == "" {
+		return errors.New("root_dir, dist_dir and db_file are required")
+	}
+	if c.Site.Name == "" || c.Site.CreatedAt.IsZero() {
+		return errors.New("site.name and site.created_at are required")
+	}
+	if c.Site.VanityHost == "" {
+		c.Site.VanityHost = u.Host
+	}
+	if c.Site.FeedItems <= 0 {
+		return errors.New("site.feed_items must be positive")
+	}
+	en := c.Site.Strings[types.LangEnglish]
+	if en.Title == "" || en.Description == "" || en.FeedTitle == "" || en.FeedDescription == "" || en.About == "" {
+		return errors.New("site.strings.en requires every text")
+	}
+	for lang := range c.Site.Strings {
+		if !slices.Contains(types.SupportedLanguages, lang) {
+			return fmt.Errorf("site.strings: unsupported language %q", lang)
+		}
+	}
+	return c.LLM.validate()
+}
+
+func (l *LLM) validate() error {
+	if len(l.Backends) == 0 {
+		return errors.New("llm.backends is required")
+	}
+	for name, b := range l.Backends {
+		if !slices.Contains(providers, b.Provider) {
+			return fmt.Errorf("llm.backends.%s: unknown provider %q", name, b.Provider)
+		}
+		if b.Model == "" {
+			return fmt.Errorf("llm.backends.%s: model is required", name)
+		}
+	}
+	if _, ok := l.Routes[TaskDefault]; !ok {
+		return errors.New("llm.routes.default is required")
+	}
+	for task, route := range l.Routes {
+		if !slices.Contains(tasks, task) {
+			return fmt.Errorf("llm.routes: unknown task %q", task)
+		}
+		if len(route) == 0 {
+			return fmt.Errorf("llm.routes.%s is empty", task)
+		}
+		for _, name := range route {
+			if _, ok := l.Backends[name]; !ok {
+				return fmt.Errorf("llm.routes.%s: unknown backend %q", task, name)
+			}
+		}
+	}
+	if l.Timeout < 0 {
+		return errors.New("llm.timeout is negative")
+	}
+	return nil
+}
+
+// Environ returns the environment of the process as a map.
+func Environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	return env
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gosuda.org/website/internal/types"
+)
+
+func TestLoad(t *testing.T) {
+	c, err := Load("../../config.jsonnet", map[string]string{
+		"SITE_URL": "https://staging.gosuda.org/",
+		"PROVIDER": "aistudio",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.BaseURL != "https://staging.gosuda.org" || c.Host() != "staging.gosuda.org" {
+		t.Errorf("BaseURL = %q, Host = %q", c.BaseURL, c.Host())
+	}
+	if c.Site.VanityHost != "gosuda.org" {
+		t.Errorf("VanityHost = %q, want the host of the import paths", c.Site.VanityHost)
+	}
+	route := c.LLM.Route(TaskTitle)
+	if len(route) == 0 || c.LLM.Backends[route[0]].Provider != "aistudio" {
+		t.Errorf("title route = %v", route)
+	}
+	for _, name := range route {
+		if b := c.LLM.Backends[name]; b.Provider != "aistudio" && b.Configured() {
+			t.Errorf("backend %s is configured without credentials", name)
+		}
+	}
+
+	en := c.Site.Localized(types.LangEnglish)
+	ko := c.Site.Localized(types.LangKorean)
+	if ko.Title == en.Title || ko.About != en.About {
+		t.Errorf("Korean texts do not fall back to English: %+v", ko)
+	}
+	if c.Site.Localized(types.LangFinnish) != en {
+		t.Error("untranslated texts are not English")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	site, err := filepath.Abs("../../config.jsonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, data := range []string{
+		`{ base_url: `,
+		`{ base_url: "https://gosuda.org", unknown: true }`,
+		`{ base_url: "gosuda.org" }`,
+		`(import "` + site + `") + { llm+: { routes+: { title: ["gpt"] } } }`,
+		`(import "` + site + `") + { llm+: { routes+: { evaluation: [] } } }`,
+		`(import "` + site + `") + { llm+: { backends+: { openai+: { provider: "gpt" } } } }`,
+		`(import "` + site + `") + { site+: { feed_items: 0 } }`,
+	} {
+		file := filepath.Join(dir, "config.jsonnet")
+		if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(file, map[string]string{}); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Load(%q) = %v, want ErrInvalidConfig", data, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package diagram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"oss.terrastruct.com/d2/d2ast"
+	"oss.terrastruct.com/d2/d2graph"
+	"oss.terrastruct.com/d2/d2layouts/d2dagrelayout"
+	"oss.terrastruct.com/d2/d2lib"
+	"oss.terrastruct.com/d2/d2parser"
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	d2log "oss.terrastruct.com/d2/lib/log"
+	"oss.terrastruct.com/d2/lib/textmeasure"
+)
+
+// d2Pad is the padding around D2 diagrams, in pixels.
+const d2Pad = 8
+
+// d2Spans parses a D2 diagram and returns the spans of the labels of its shapes and
+// connections, and of their label fields. Values of other reserved keywords, e.g. styles
+// and shapes, are not labels.
+func d2Spans(source string) ([]Span, error) {
+	m, err := d2parser.Parse("", strings.NewReader(source), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+
+	var spans []Span
+	var walk func(m *d2ast.Map)
+	walk = func(m *d2ast.Map) {
+		for _, node := range m.Nodes {
+			k := node.MapKey
+			if k == nil || d2Reserved(k.Key) || d2Reserved(k.EdgeKey) {
+				if k != nil && d2Label(k) {
+					spans = d2Span(spans, k.Value.ScalarBox())
+				}
+				continue
+			}
+			if k.Value.Map != nil {
+				spans = d2Span(spans, k.Primary)
+				walk(k.Value.Map)
+			} else {
+				spans = d2Span(spans, k.Value.ScalarBox())
+			}
+		}
+	}
+	walk(m)
+	return spans, nil
+}
+
+// d2Reserved reports whether a key path contains a reserved keyword, other than the
+// keywords of boards whose shapes have labels.
+func d2Reserved(path *d2ast.KeyPath) bool {
+	if path == nil {
+		return false
+	}
+	for _, s := range path.Path {
+		keyword := strings.ToLower(s.Unbox().ScalarString())
+		if _, ok := d2ast.ReservedKeywords[keyword]; ok {
+			if _, board := d2ast.BoardKeywords[keyword]; !board {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// d2Label reports whether the key sets the label field of a shape or connection.
+func d2Label(k *d2ast.Key) bool {
+	path := k.EdgeKey
+	if path == nil {
+		path = k.Key
+	}
+	if path == nil || len(path.Path) == 0 {
+		return false
+	}
+	last := path.Path[len(path.Path)-1].Unbox().ScalarString()
+	return strings.EqualFold(last, "label") && (len(path.Path) == 1 || !d2Reserved(&d2ast.KeyPath{Path: path.Path[:len(path.Path)-1]}))
+}
+
+// d2Span appends the span of a label. Only plain strings are translated: block strings
+// hold Markdown or code, and strings with substitutions are built from variables.
+func d2Span(spans []Span, s d2ast.ScalarBox) []Span {
+	switch {
+	case s.UnquotedString != nil && !d2Substitutes(s.UnquotedString.Value):
+		r := s.UnquotedString.Range
+		return append(spans, Span{Start: r.Start.Byte, End: r.End.Byte})
+	case s.DoubleQuotedString != nil && !d2Substitutes(s.DoubleQuotedString.Value):
+		r := s.DoubleQuotedString.Range
+		return append(spans, Span{Start: r.Start.Byte + 1, End: r.End.Byte - 1, Quoted: true})
+	}
+	return spans
+}
+
+func d2Substitutes(value []d2ast.InterpolationBox) bool {
+	for _, v := range value {
+		if v.Substitution != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// d2Context discards the logs of D2, which warns about every diagram laid out without
+// a logger.
+var d2Context = d2log.With(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+// renderD2 renders a D2 diagram to an SVG element whose ids are derived from id.
+func renderD2(source, id string) (string, error) {
+	ruler, err := textmeasure.NewRuler()
+	if err != nil {
+		return "", err
+	}
+	pad := int64(d2Pad)
+	noXMLTag, omitVersion := true, true
+	opts := &d2svg.RenderOpts{Pad: &pad, NoXMLTag: &noXMLTag, OmitVersion: &omitVersion, Salt: &id}
+	layout := func(string) (d2graph.LayoutGraph, error) {
+		return d2dagrelayout.DefaultLayout, nil
+	}
+
+	diagram, _, err := d2lib.Compile(d2Context, source, &d2lib.CompileOptions{LayoutResolver: layout, Ruler: ruler}, opts)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+	svg, err := d2svg.Render(diagram, opts)
+	if err != nil {
+		return "", err
+	}
+	return `<svg class="diagram"` + strings.TrimPrefix(string(svg), "<svg"), nil
+}
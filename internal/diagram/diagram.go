@@ -0,0 +1,237 @@
+// Package diagram renders Mermaid flowcharts, Graphviz DOT graphs and D2 diagrams to
+// SVG. DOT graphs are laid out by Graphviz, compiled to WebAssembly, and D2 diagrams by
+// the D2 library; Mermaid flowcharts, which have no Go implementation, are parsed and laid
+// out by this package.
+package diagram
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Version is incremented whenever the rendered SVG changes, to invalidate cached diagrams.
+const Version = 2
+
+var (
+	ErrUnsupported = errors.New("unsupported diagram")
+	ErrSyntax      = errors.New("invalid diagram syntax")
+)
+
+// Languages are the code block languages rendered as diagrams.
+var Languages = []string{"mermaid", "dot", "graphviz", "d2"}
+
+// IsLanguage reports whether code blocks of lang are rendered as diagrams.
+func IsLanguage(lang string) bool {
+	return slices.Contains(Languages, strings.ToLower(lang))
+}
+
+// Direction is the direction in which the layers of a graph are laid out.
+type Direction int
+
+const (
+	TopDown Direction = iota
+	BottomUp
+	LeftRight
+	RightLeft
+)
+
+// Shape is the outline of a node.
+type Shape int
+
+const (
+	ShapeRect Shape = iota
+	ShapeRounded
+	ShapeEllipse
+	ShapeCircle
+	ShapeDiamond
+	ShapePlain
+)
+
+type Graph struct {
+	Direction Direction
+	Nodes     []*Node
+	Edges     []*Edge
+}
+
+type Node struct {
+	ID    string
+	Label string
+	Shape Shape
+}
+
+type Edge struct {
+	From, To   *Node
+	Label      string
+	Dashed     bool
+	ArrowStart bool
+	ArrowEnd   bool
+}
+
+// Span is the position of a translatable label in the source of a diagram.
+type Span struct {
+	Start, End int
+	// Quoted reports whether the span is the content of a quoted string.
+	Quoted bool
+}
+
+// builder collects the nodes, edges and label spans of a diagram while parsing.
+type builder struct {
+	g     *Graph
+	byID  map[string]*Node
+	spans []Span
+	shape Shape
+}
+
+func newBuilder(defaultShape Shape) *builder {
+	return &builder{g: &Graph{}, byID: make(map[string]*Node), shape: defaultShape}
+}
+
+// node returns the node with id, creating it with the default shape if needed.
+func (b *builder) node(id string) *Node {
+	if n, ok := b.byID[id]; ok {
+		return n
+	}
+	n := &Node{ID: id, Label: id, Shape: b.shape}
+	b.byID[id] = n
+	b.g.Nodes = append(b.g.Nodes, n)
+	return n
+}
+
+func (b *builder) edge(from, to *Node) *Edge {
+	e := &Edge{From: from, To: to, ArrowEnd: true}
+	b.g.Edges = append(b.g.Edges, e)
+	return e
+}
+
+// span records a translatable label; empty labels are not recorded.
+func (b *builder) span(start, end int, quoted bool) {
+	if start >= 0 && end > start {
+		b.spans = append(b.spans, Span{Start: start, End: end, Quoted: quoted})
+	}
+}
+
+// Parse parses the source of a Mermaid flowchart. Other languages are not parsed into
+// graphs, as they are rendered by their own libraries.
+func Parse(lang, source string) (*Graph, error) {
+	if strings.ToLower(lang) != "mermaid" {
+		return nil, ErrUnsupported
+	}
+	g, _, err := parseMermaid(source)
+	return g, err
+}
+
+// labelSpans returns the translatable labels of a Mermaid or D2 diagram.
+func labelSpans(lang, source string) ([]Span, error) {
+	switch strings.ToLower(lang) {
+	case "mermaid":
+		_, spans, err := parseMermaid(source)
+		return spans, err
+	case "d2":
+		return d2Spans(source)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// Labels returns the translatable labels of a diagram, in source order.
+func Labels(lang, source string) ([]string, error) {
+	var labels []string
+	switch strings.ToLower(lang) {
+	case "dot", "graphviz":
+		texts, err := dotLabelTexts(source)
+		if err != nil {
+			return nil, err
+		}
+		labels = texts
+	default:
+		spans, err := labelSpans(lang, source)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sortSpans(spans) {
+			labels = append(labels, source[s.Start:s.End])
+		}
+	}
+
+	for i, label := range labels {
+		labels[i] = strings.Join(strings.Fields(unescapeLabel(label)), " ")
+	}
+	return labels, nil
+}
+
+// Relabel replaces the translatable labels of a diagram, in the order returned by Labels.
+// The syntax of Mermaid and D2 diagrams is left unchanged; DOT graphs are written back by
+// Graphviz in canonical form.
+func Relabel(lang, source string, labels []string) (string, error) {
+	if l := strings.ToLower(lang); l == "dot" || l == "graphviz" {
+		return relabelDot(source, labels)
+	}
+
+	spans, err := labelSpans(lang, source)
+	if err != nil {
+		return "", err
+	}
+	spans = sortSpans(spans)
+	if len(labels) != len(spans) {
+		return "", ErrSyntax
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, s := range spans {
+		b.WriteString(source[last:s.Start])
+		b.WriteString(escapeLabel(lang, labels[i], s.Quoted))
+		last = s.End
+	}
+	b.WriteString(source[last:])
+	return b.String(), nil
+}
+
+// Render renders the source of a diagram written in lang to an SVG element. The ids of
+// its elements are derived from id, which must be unique in the page.
+func Render(lang, source, id string) (string, error) {
+	switch strings.ToLower(lang) {
+	case "mermaid":
+		return renderMermaid(source, id)
+	case "dot", "graphviz":
+		return renderDot(source, id)
+	case "d2":
+		return renderD2(source, id)
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+func sortSpans(spans []Span) []Span {
+	spans = slices.Clone(spans)
+	slices.SortFunc(spans, func(a, b Span) int { return a.Start - b.Start })
+	return slices.CompactFunc(spans, func(a, b Span) bool { return a.Start == b.Start })
+}
+
+// escapeLabel makes a translated label safe to insert in place of a label of lang.
+func escapeLabel(lang, label string, quoted bool) string {
+	label = strings.Join(strings.Fields(label), " ")
+	switch strings.ToLower(lang) {
+	case "mermaid":
+		if quoted {
+			return strings.ReplaceAll(label, `"`, "#quot;")
+		}
+		return strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[](){}|";`, r) {
+				return -1
+			}
+			return r
+		}, label)
+	default:
+		if quoted {
+			return strings.ReplaceAll(label, `"`, `\"`)
+		}
+		return strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`;{}#`, r) {
+				return -1
+			}
+			return r
+		}, label)
+	}
+}
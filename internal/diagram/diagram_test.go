@@ -0,0 +1,200 @@
+package diagram
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		lang, source string
+		direction    Direction
+		nodes        []string
+		edges        []string
+	}{
+		{
+			lang:      "mermaid",
+			source:    "flowchart LR\n  A[Client] -->|request| B(Server)\n  B -.-> C{Cache?} & D[(DB)]\n  %% comment\n  style A fill:#f9f\n",
+			direction: LeftRight,
+			nodes:     []string{"Client", "Server", "Cache?", "DB"},
+			edges:     []string{"A>B:request", "B>C", "B>D"},
+		},
+		{
+			lang:      "mermaid",
+			source:    "graph TD\nA -- yes --> B[\"Done\"]; B --- A",
+			direction: TopDown,
+			nodes:     []string{"A", "Done"},
+			edges:     []string{"A>B:yes", "B-A"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.source, func(t *testing.T) {
+			g, err := Parse(tc.lang, tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Direction != tc.direction {
+				t.Errorf("expected direction %d, got %d", tc.direction, g.Direction)
+			}
+
+			var nodes, edges []string
+			for _, n := range g.Nodes {
+				nodes = append(nodes, n.Label)
+			}
+			for _, e := range g.Edges {
+				arrow := "-"
+				if e.ArrowEnd {
+					arrow = ">"
+				}
+				edge := e.From.ID + arrow + e.To.ID
+				if e.Label != "" {
+					edge += ":" + e.Label
+				}
+				edges = append(edges, edge)
+			}
+			if !slices.Equal(nodes, tc.nodes) {
+				t.Errorf("expected nodes %q, got %q", tc.nodes, nodes)
+			}
+			if !slices.Equal(edges, tc.edges) {
+				t.Errorf("expected edges %q, got %q", tc.edges, edges)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		lang, source string
+		labels       []string
+	}{
+		{
+			lang:   "mermaid",
+			source: "flowchart LR\n  A[Client] -->|request| B(Server)\n",
+			labels: []string{"Client", "request", "Server"},
+		},
+		{
+			lang:   "dot",
+			source: "digraph G {\n  rankdir=LR; // comment\n  node [shape=box]\n  a [label=\"Start\"];\n  a -> b -> c [label=next, style=dashed]\n  subgraph cluster_x { c -> a }\n}\n",
+			labels: []string{"Start", "next", ">c<"},
+		},
+		{
+			lang:   "graphviz",
+			source: "graph { x -- y }",
+			labels: []string{">x<", ">y<"},
+		},
+		{
+			lang:   "d2",
+			source: "direction: right\n# comment\nuser: User {\n  shape: circle\n}\nuser -> api: calls\napi: \"API Server\"\n",
+			labels: []string{"User", "calls", "API Server"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.lang, func(t *testing.T) {
+			svg, err := Render(tc.lang, tc.source, "diagram-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, `class="diagram"`) {
+				t.Errorf("unexpected SVG %.200s", svg)
+			}
+			for _, label := range tc.labels {
+				if !strings.Contains(svg, label) {
+					t.Errorf("SVG does not contain %q", label)
+				}
+			}
+
+			// The ids of diagrams rendered with different ids must not collide, e.g. those of
+			// the arrowheads of two identical diagrams in a page.
+			other, err := Render(tc.lang, tc.source, "diagram-2")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := svgIDs(svg)
+			if len(ids) == 0 {
+				t.Fatal("SVG has no ids")
+			}
+			for _, id := range svgIDs(other) {
+				if slices.Contains(ids, id) {
+					t.Errorf("id %q is in both diagrams", id)
+				}
+			}
+		})
+	}
+}
+
+func svgIDs(svg string) []string {
+	var ids []string
+	for _, m := range regexp.MustCompile(`\bid="([^"]*)"`).FindAllStringSubmatch(svg, -1) {
+		ids = append(ids, m[1])
+	}
+	return ids
+}
+
+func TestUnsupported(t *testing.T) {
+	_, err := Parse("mermaid", "sequenceDiagram\n  Alice->>Bob: Hi")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestRelabel(t *testing.T) {
+	testCases := []struct {
+		lang, source string
+		labels       []string
+		translated   []string
+		expected     string
+	}{
+		{
+			lang:       "mermaid",
+			source:     "graph TD\n  A[Start<br>here] -->|\"go\"| B(End)\n",
+			labels:     []string{"Start here", "go", "End"},
+			translated: []string{"시작 [여기]", `"가기"`, "끝"},
+			expected:   "graph TD\n  A[시작 여기] -->|\"#quot;가기#quot;\"| B(끝)\n",
+		},
+		{
+			lang:       "dot",
+			source:     "digraph { a [label=Start]; a -> b [label=\"next step\"] }",
+			labels:     []string{"Start", "next step"},
+			translated: []string{"시작", "다음 단계"},
+			expected:   "digraph {\n\ta\t[label=시작];\n\ta -> b\t[label=\"다음 단계\"];\n}\n",
+		},
+		{
+			lang:       "d2",
+			source:     "a: Client\na -> b: sends {\n  style.stroke: red\n}\n",
+			labels:     []string{"Client", "sends"},
+			translated: []string{"클라이언트", "보냄 #1"},
+			expected:   "a: 클라이언트\na -> b: 보냄 1 {\n  style.stroke: red\n}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.lang, func(t *testing.T) {
+			labels, err := Labels(tc.lang, tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(labels, tc.labels) {
+				t.Errorf("expected labels %q, got %q", tc.labels, labels)
+			}
+
+			relabeled, err := Relabel(tc.lang, tc.source, tc.translated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if relabeled != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, relabeled)
+			}
+			labels, err = Labels(tc.lang, relabeled)
+			if err != nil {
+				t.Errorf("relabeled diagram does not parse: %v", err)
+			} else if len(labels) != len(tc.labels) {
+				t.Errorf("expected %d labels in the relabeled diagram, got %q", len(tc.labels), labels)
+			}
+		})
+	}
+}
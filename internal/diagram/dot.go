@@ -0,0 +1,150 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-graphviz"
+)
+
+// gvOnce creates the Graphviz instance shared by DOT diagrams. Graphviz runs in a
+// WebAssembly runtime which is not safe for concurrent use, so calls are serialized with
+// gvMu.
+var (
+	gvMu   sync.Mutex
+	gvOnce = sync.OnceValues(func() (*graphviz.Graphviz, error) {
+		return graphviz.New(context.Background())
+	})
+)
+
+// dotGraph parses source with Graphviz and calls f with the graph.
+func dotGraph(source string, f func(gv *graphviz.Graphviz, g *graphviz.Graph) error) error {
+	gvMu.Lock()
+	defer gvMu.Unlock()
+
+	gv, err := gvOnce()
+	if err != nil {
+		return err
+	}
+	g, err := graphviz.ParseBytes([]byte(source))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+	defer g.Close()
+	return f(gv, g)
+}
+
+// dotLabel is a label attribute of a node or an edge.
+type dotLabel struct {
+	text string
+	set  func(string) error
+}
+
+// dotLabels returns the explicit labels of the nodes and edges of g, in the order in
+// which Graphviz lists them. Nodes labelled with their name and HTML-like labels have no
+// translatable text.
+func dotLabels(g *graphviz.Graph) ([]dotLabel, error) {
+	var labels []dotLabel
+	add := func(text string, set func(string) error) {
+		if text == "" || text == `\N` || strings.HasPrefix(text, "<") && strings.HasSuffix(text, ">") {
+			return
+		}
+		labels = append(labels, dotLabel{text: text, set: set})
+	}
+
+	for n, err := g.FirstNode(); n != nil || err != nil; n, err = g.NextNode(n) {
+		if err != nil {
+			return nil, err
+		}
+		add(n.GetStr("label"), func(s string) error { return n.SafeSet("label", s, "") })
+
+		for e, err := g.FirstOut(n); e != nil || err != nil; e, err = g.NextOut(e) {
+			if err != nil {
+				return nil, err
+			}
+			add(e.GetStr("label"), func(s string) error { return e.SafeSet("label", s, "") })
+		}
+	}
+	return labels, nil
+}
+
+func dotLabelTexts(source string) ([]string, error) {
+	var texts []string
+	err := dotGraph(source, func(_ *graphviz.Graphviz, g *graphviz.Graph) error {
+		labels, err := dotLabels(g)
+		for _, l := range labels {
+			texts = append(texts, l.text)
+		}
+		return err
+	})
+	return texts, err
+}
+
+// relabelDot replaces the labels returned by dotLabels, and writes the graph back in
+// canonical DOT.
+func relabelDot(source string, texts []string) (string, error) {
+	var b bytes.Buffer
+	err := dotGraph(source, func(gv *graphviz.Graphviz, g *graphviz.Graph) error {
+		labels, err := dotLabels(g)
+		if err != nil {
+			return err
+		}
+		if len(labels) != len(texts) {
+			return ErrSyntax
+		}
+		for i, l := range labels {
+			err = l.set(strings.Join(strings.Fields(texts[i]), " "))
+			if err != nil {
+				return err
+			}
+		}
+		return gv.Render(context.Background(), g, "canon", &b)
+	})
+	if err != nil {
+		return "", err
+	}
+	// Render lays the graph out first, which is not part of the source.
+	canon := dotLayoutAttr.ReplaceAllString(b.String(), "")
+	return dotEmptyAttrs.ReplaceAllString(canon, ""), nil
+}
+
+// dotLayoutAttr matches the attributes Graphviz adds once it has laid out a graph: the
+// bounding box of the graph and the default label of nodes, their name.
+var dotLayoutAttr = regexp.MustCompile(`\b(bb="[^"]*"|label="\\N"),?\s*`)
+
+// dotEmptyAttrs matches the default attribute statements left empty by dotLayoutAttr.
+var dotEmptyAttrs = regexp.MustCompile(`(?m)^\s*(graph|node|edge) \[\];\n`)
+
+// dotColors makes the black strokes and texts of Graphviz follow the surrounding text,
+// and leaves the background transparent.
+var dotColors = strings.NewReplacer(
+	`fill="white" stroke="none"`, `fill="none" stroke="none"`,
+	`stroke="black"`, `stroke="currentColor"`,
+	`fill="black"`, `fill="currentColor"`,
+	` font-family="Times,serif"`, "",
+)
+
+// renderDot renders a DOT graph to an SVG element whose ids start with id.
+func renderDot(source, id string) (string, error) {
+	var b bytes.Buffer
+	err := dotGraph(source, func(gv *graphviz.Graphviz, g *graphviz.Graph) error {
+		return gv.Render(context.Background(), g, graphviz.SVG, &b)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	svg := b.String()
+	// Drop the XML declaration, doctype and comments before the root element.
+	start := strings.Index(svg, "<svg")
+	if start < 0 {
+		return "", ErrSyntax
+	}
+	svg = strings.TrimSpace(svg[start:])
+	svg = `<svg class="diagram" fill="currentColor"` + svg[len("<svg"):]
+	return scopeIDs(dotColors.Replace(svg), id), nil
+}
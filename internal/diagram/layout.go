@@ -0,0 +1,480 @@
+package diagram
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	fontSize   = 14.0
+	lineHeight = 18.0
+	paddingX   = 12.0
+	paddingY   = 8.0
+	minWidth   = 48.0
+	nodeSep    = 24.0
+	rankSep    = 48.0
+	margin     = 8.0
+	loopSize   = 28.0
+	edgeSep    = 16.0
+	// sweeps is the number of barycenter passes that reduce edge crossings.
+	sweeps = 8
+)
+
+type point struct {
+	X, Y float64
+}
+
+// vertex is a node of the layered graph; vertices without a node route long edges across layers.
+type vertex struct {
+	node  *Node
+	lines []string
+	rank  int
+	w, h  float64
+	x, y  float64
+	// up and down are the neighbouring vertices in the previous and next layer.
+	up, down []int
+}
+
+type route struct {
+	edge   *Edge
+	points []point
+	// side is the unit vector from a group of parallel edges towards this edge, if any.
+	side point
+}
+
+type layout struct {
+	width, height float64
+	vertices      []*vertex
+	routes        []*route
+}
+
+// textWidth estimates the rendered width of s. Wide characters, e.g. CJK, take a full em.
+func textWidth(s string) float64 {
+	w := 0.0
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) || (r >= 0xff00 && r <= 0xffef):
+			w += fontSize
+		case unicode.IsSpace(r):
+			w += 0.3 * fontSize
+		case unicode.IsUpper(r) || unicode.IsDigit(r):
+			w += 0.62 * fontSize
+		default:
+			w += 0.55 * fontSize
+		}
+	}
+	return w
+}
+
+func labelLines(label string) []string {
+	lines := strings.Split(label, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines
+}
+
+// labelSize returns the size of the text box of label.
+func labelSize(lines []string) (float64, float64) {
+	w := 0.0
+	for _, line := range lines {
+		w = max(w, textWidth(line))
+	}
+	return w, float64(len(lines)) * lineHeight
+}
+
+func nodeSize(n *Node, lines []string) (float64, float64) {
+	tw, th := labelSize(lines)
+	w, h := max(minWidth, tw+2*paddingX), th+2*paddingY
+	switch n.Shape {
+	case ShapeEllipse:
+		return w * 1.25, h * 1.25
+	case ShapeCircle:
+		d := math.Hypot(tw+paddingX, th+paddingY)
+		return d, d
+	case ShapeDiamond:
+		return w * 1.8, h * 1.8
+	default:
+		return w, h
+	}
+}
+
+// layoutGraph places the nodes of g in layers along its direction and routes its edges.
+func layoutGraph(g *Graph) *layout {
+	l := &layout{}
+	index := make(map[*Node]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n] = i
+		v := &vertex{node: n, lines: labelLines(n.Label)}
+		v.w, v.h = nodeSize(n, v.lines)
+		l.vertices = append(l.vertices, v)
+	}
+
+	// Orient every edge along the layers, reversing the edges that close a cycle.
+	reversed := backEdges(g, index)
+	type arc struct{ from, to int }
+	arcs := make([]arc, len(g.Edges))
+	for i, e := range g.Edges {
+		arcs[i] = arc{index[e.From], index[e.To]}
+		if reversed[i] {
+			arcs[i] = arc{index[e.To], index[e.From]}
+		}
+	}
+
+	// Assign each vertex to the layer after its deepest predecessor. Once cycles
+	// are broken the arcs form a DAG, so this settles within one pass per vertex.
+	for changed := true; changed; {
+		changed = false
+		for _, a := range arcs {
+			if a.from == a.to {
+				continue
+			}
+			if r := l.vertices[a.from].rank + 1; r > l.vertices[a.to].rank {
+				l.vertices[a.to].rank = r
+				changed = true
+			}
+		}
+	}
+
+	// Split long edges into chains of vertices, one per layer.
+	chains := make([][]int, len(arcs))
+	for i, a := range arcs {
+		if a.from == a.to {
+			chains[i] = []int{a.from}
+			continue
+		}
+		chain := []int{a.from}
+		prev := a.from
+		for r := l.vertices[a.from].rank + 1; r < l.vertices[a.to].rank; r++ {
+			l.vertices = append(l.vertices, &vertex{rank: r})
+			next := len(l.vertices) - 1
+			l.link(prev, next)
+			chain = append(chain, next)
+			prev = next
+		}
+		l.link(prev, a.to)
+		chains[i] = append(chain, a.to)
+	}
+
+	layers := l.order()
+	l.place(g, layers)
+
+	// Edges between the same nodes are drawn side by side.
+	type pair struct{ a, b *Node }
+	parallel := make(map[pair][]int)
+	key := func(e *Edge) pair {
+		if index[e.From] > index[e.To] {
+			return pair{e.To, e.From}
+		}
+		return pair{e.From, e.To}
+	}
+	for i, e := range g.Edges {
+		if e.From != e.To {
+			parallel[key(e)] = append(parallel[key(e)], i)
+		}
+	}
+
+	for i, e := range g.Edges {
+		chain := slices.Clone(chains[i])
+		if reversed[i] {
+			slices.Reverse(chain)
+		}
+		r := l.route(e, chain)
+		if group := parallel[key(e)]; len(group) > 1 {
+			k := slices.Index(group, i)
+			r.shift((float64(k) - float64(len(group)-1)/2) * edgeSep)
+		}
+		l.routes = append(l.routes, r)
+	}
+
+	l.fit()
+	return l
+}
+
+// fit moves the layout to the origin and sizes it to its content, including edge labels and self loops.
+func (l *layout) fit() {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	extend := func(x, y, w, h float64) {
+		minX, minY = min(minX, x-w/2), min(minY, y-h/2)
+		maxX, maxY = max(maxX, x+w/2), max(maxY, y+h/2)
+	}
+	for _, v := range l.vertices {
+		if v.node != nil {
+			extend(v.x, v.y, v.w, v.h)
+		}
+	}
+	for _, r := range l.routes {
+		for _, p := range r.points {
+			extend(p.X, p.Y, 0, 0)
+		}
+		if r.edge.Label != "" {
+			w, h := labelSize(labelLines(r.edge.Label))
+			c := r.labelCenter(w, h)
+			extend(c.X, c.Y, w+8, h)
+		}
+	}
+
+	dx, dy := margin-minX, margin-minY
+	for _, v := range l.vertices {
+		v.x += dx
+		v.y += dy
+	}
+	for _, r := range l.routes {
+		for i := range r.points {
+			r.points[i].X += dx
+			r.points[i].Y += dy
+		}
+	}
+	l.width, l.height = maxX-minX+2*margin, maxY-minY+2*margin
+}
+
+// shift moves a route sideways by d, perpendicular to the line between its ends.
+func (r *route) shift(d float64) {
+	first, last := r.points[0], r.points[len(r.points)-1]
+	dx, dy := last.X-first.X, last.Y-first.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	// Edges in opposite directions share the same normal.
+	if dx < 0 || (dx == 0 && dy < 0) {
+		dx, dy = -dx, -dy
+	}
+	nx, ny := -dy/length, dx/length
+	for i := range r.points {
+		r.points[i].X += nx * d
+		r.points[i].Y += ny * d
+	}
+	if d != 0 {
+		r.side = point{nx * math.Copysign(1, d), ny * math.Copysign(1, d)}
+	}
+}
+
+// labelCenter returns the center of the label of a route of size w by h. The labels of
+// parallel edges are moved aside so that they do not overlap.
+func (r *route) labelCenter(w, h float64) point {
+	c := midpoint(r.points)
+	d := math.Abs(r.side.X)*(w/2+4) + math.Abs(r.side.Y)*(h/2+2)
+	return point{c.X + r.side.X*d, c.Y + r.side.Y*d}
+}
+
+// backEdges returns the edges that close a cycle in a depth-first search of g.
+func backEdges(g *Graph, index map[*Node]int) []bool {
+	out := make([][]int, len(g.Nodes))
+	for i, e := range g.Edges {
+		out[index[e.From]] = append(out[index[e.From]], i)
+	}
+
+	reversed := make([]bool, len(g.Edges))
+	state := make([]int, len(g.Nodes)) // 0: unvisited, 1: on the stack, 2: done
+	var visit func(v int)
+	visit = func(v int) {
+		state[v] = 1
+		for _, i := range out[v] {
+			w := index[g.Edges[i].To]
+			switch state[w] {
+			case 0:
+				visit(w)
+			case 1:
+				reversed[i] = w != v
+			}
+		}
+		state[v] = 2
+	}
+	for v := range g.Nodes {
+		if state[v] == 0 {
+			visit(v)
+		}
+	}
+	return reversed
+}
+
+func (l *layout) link(from, to int) {
+	l.vertices[from].down = append(l.vertices[from].down, to)
+	l.vertices[to].up = append(l.vertices[to].up, from)
+}
+
+// order groups the vertices into layers and orders each layer to reduce edge crossings.
+func (l *layout) order() [][]int {
+	var layers [][]int
+	for i, v := range l.vertices {
+		for len(layers) <= v.rank {
+			layers = append(layers, nil)
+		}
+		layers[v.rank] = append(layers[v.rank], i)
+	}
+
+	pos := make([]float64, len(l.vertices))
+	for _, layer := range layers {
+		for i, v := range layer {
+			pos[v] = float64(i)
+		}
+	}
+
+	for sweep := 0; sweep < sweeps; sweep++ {
+		down := sweep%2 == 0
+		for k := range layers {
+			r := k
+			if !down {
+				r = len(layers) - 1 - k
+			}
+			layer := layers[r]
+
+			bary := make(map[int]float64, len(layer))
+			for _, v := range layer {
+				neighbours := l.vertices[v].up
+				if !down {
+					neighbours = l.vertices[v].down
+				}
+				if len(neighbours) == 0 {
+					bary[v] = pos[v]
+					continue
+				}
+				sum := 0.0
+				for _, u := range neighbours {
+					sum += pos[u]
+				}
+				bary[v] = sum / float64(len(neighbours))
+			}
+			slices.SortStableFunc(layer, func(a, b int) int {
+				switch {
+				case bary[a] < bary[b]:
+					return -1
+				case bary[a] > bary[b]:
+					return 1
+				}
+				return 0
+			})
+			for i, v := range layer {
+				pos[v] = float64(i)
+			}
+		}
+	}
+	return layers
+}
+
+// place computes the coordinates of the vertices and the size of the layout.
+func (l *layout) place(g *Graph, layers [][]int) {
+	horizontal := g.Direction == LeftRight || g.Direction == RightLeft
+
+	// The main axis runs across the layers, the cross axis along each layer.
+	size := func(v *vertex) (float64, float64) {
+		if horizontal {
+			return v.w, v.h
+		}
+		return v.h, v.w
+	}
+
+	// Edge labels are drawn between layers, which are spaced apart to make room for them.
+	gap := rankSep
+	for _, e := range g.Edges {
+		if e.Label == "" {
+			continue
+		}
+		w, h := labelSize(labelLines(e.Label))
+		if horizontal {
+			gap = max(gap, w+2*paddingX)
+		} else {
+			gap = max(gap, rankSep+h)
+		}
+	}
+
+	extents := make([]float64, len(layers))
+	crossSize := 0.0
+	for r, layer := range layers {
+		for i, v := range layer {
+			_, cross := size(l.vertices[v])
+			if i > 0 {
+				extents[r] += nodeSep
+			}
+			extents[r] += cross
+		}
+		crossSize = max(crossSize, extents[r])
+	}
+
+	main := 0.0
+	for r, layer := range layers {
+		thickness := 0.0
+		for _, v := range layer {
+			m, _ := size(l.vertices[v])
+			thickness = max(thickness, m)
+		}
+
+		cross := (crossSize - extents[r]) / 2
+		for _, v := range layer {
+			vx := l.vertices[v]
+			_, c := size(vx)
+			if horizontal {
+				vx.x, vx.y = main+thickness/2, cross+c/2
+			} else {
+				vx.x, vx.y = cross+c/2, main+thickness/2
+			}
+			cross += c + nodeSep
+		}
+		main += thickness
+		if r < len(layers)-1 {
+			main += gap
+		}
+	}
+
+	for _, v := range l.vertices {
+		switch g.Direction {
+		case BottomUp:
+			v.y = main - v.y
+		case RightLeft:
+			v.x = main - v.x
+		}
+	}
+}
+
+// route returns the points of an edge through chain, clipped to the outlines of its end nodes.
+func (l *layout) route(e *Edge, chain []int) *route {
+	if len(chain) == 1 {
+		v := l.vertices[chain[0]]
+		right := v.x + v.w/2
+		return &route{edge: e, points: []point{
+			{right, v.y - v.h/4},
+			{right + loopSize, v.y - v.h/2},
+			{right + loopSize, v.y + v.h/2},
+			{right, v.y + v.h/4},
+		}}
+	}
+
+	points := make([]point, len(chain))
+	for i, v := range chain {
+		points[i] = point{l.vertices[v].x, l.vertices[v].y}
+	}
+	first, last := l.vertices[chain[0]], l.vertices[chain[len(chain)-1]]
+	points[0] = clip(first, points[1])
+	points[len(points)-1] = clip(last, points[len(points)-2])
+	return &route{edge: e, points: points}
+}
+
+// clip returns the point where the line from the center of v to p crosses the outline of v.
+func clip(v *vertex, p point) point {
+	dx, dy := p.X-v.x, p.Y-v.y
+	if dx == 0 && dy == 0 {
+		return p
+	}
+	a, b := v.w/2, v.h/2
+
+	var t float64
+	switch v.node.Shape {
+	case ShapeEllipse, ShapeCircle:
+		t = 1 / math.Hypot(dx/a, dy/b)
+	case ShapeDiamond:
+		t = 1 / (math.Abs(dx)/a + math.Abs(dy)/b)
+	default:
+		t = math.Inf(1)
+		if dx != 0 {
+			t = a / math.Abs(dx)
+		}
+		if dy != 0 {
+			t = min(t, b/math.Abs(dy))
+		}
+	}
+	return point{v.x + t*dx, v.y + t*dy}
+}
@@ -0,0 +1,274 @@
+package diagram
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	mermaidHeaderRegex = regexp.MustCompile(`^(?:graph|flowchart)(?:\s+(TD|TB|BT|LR|RL))?\s*;?\s*$`)
+	// A link with its text between the dashes, e.g. "-- yes -->" or "-. maybe .->".
+	mermaidTextLinkRegex = regexp.MustCompile(`^\s*(<?)(--|==|-\.)\s+([^|>]+?)\s+(-{2,}[->ox]?|={2,}[=>ox]?|\.+-[->ox]?)`)
+	mermaidLinkRegex     = regexp.MustCompile(`^\s*(<?)(-{2,}|={2,}|-\.+-)([->ox]?)`)
+	mermaidSkipRegex     = regexp.MustCompile(`^(?:subgraph|end|style|classDef|class|linkStyle|click|direction)\b`)
+)
+
+// mermaidShapes are the delimiters of mermaid node labels and their shapes, longest first.
+var mermaidShapes = []struct {
+	open, close string
+	shape       Shape
+}{
+	{"(((", ")))", ShapeCircle},
+	{"((", "))", ShapeCircle},
+	{"([", "])", ShapeRounded},
+	{"[[", "]]", ShapeRect},
+	{"[(", ")]", ShapeRect},
+	{"{{", "}}", ShapeDiamond},
+	{"[/", "/]", ShapeRect},
+	{"[\\", "\\]", ShapeRect},
+	{"[", "]", ShapeRect},
+	{"(", ")", ShapeRounded},
+	{"{", "}", ShapeDiamond},
+	{">", "]", ShapeRect},
+}
+
+// parseMermaid parses a mermaid flowchart. Other mermaid diagram types are not supported.
+func parseMermaid(source string) (*Graph, []Span, error) {
+	b := newBuilder(ShapeRect)
+	header := false
+
+	offset := 0
+	for _, line := range strings.SplitAfter(source, "\n") {
+		start := offset
+		offset += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
+			continue
+		}
+		if !header {
+			m := mermaidHeaderRegex.FindStringSubmatch(trimmed)
+			if m == nil {
+				return nil, nil, ErrUnsupported
+			}
+			switch m[1] {
+			case "BT":
+				b.g.Direction = BottomUp
+			case "LR":
+				b.g.Direction = LeftRight
+			case "RL":
+				b.g.Direction = RightLeft
+			}
+			header = true
+			continue
+		}
+		if mermaidSkipRegex.MatchString(trimmed) {
+			continue
+		}
+
+		p := &mermaidParser{b: b, src: source, pos: start, end: start + len(strings.TrimRight(line, "\r\n"))}
+		for p.pos < p.end {
+			err := p.statement()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if !header {
+		return nil, nil, ErrUnsupported
+	}
+	return b.g, b.spans, nil
+}
+
+type mermaidParser struct {
+	b        *builder
+	src      string
+	pos, end int
+}
+
+func (p *mermaidParser) rest() string {
+	return p.src[p.pos:p.end]
+}
+
+func (p *mermaidParser) skipSpace() {
+	for p.pos < p.end && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// statement parses a chain of node groups joined by links, up to the end of the line or a semicolon.
+func (p *mermaidParser) statement() error {
+	from, err := p.nodeGroup()
+	if err != nil {
+		return err
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= p.end {
+			return nil
+		}
+		if p.src[p.pos] == ';' {
+			p.pos++
+			return nil
+		}
+
+		link, ok := p.link()
+		if !ok {
+			return ErrSyntax
+		}
+		to, err := p.nodeGroup()
+		if err != nil {
+			return err
+		}
+
+		for i, a := range from {
+			for _, c := range to {
+				e := p.b.edge(a, c)
+				e.Label, e.Dashed, e.ArrowStart, e.ArrowEnd = link.Label, link.Dashed, link.ArrowStart, link.ArrowEnd
+			}
+			if i == 0 {
+				p.b.span(link.start, link.end, link.quoted)
+			}
+		}
+		from = to
+	}
+}
+
+type mermaidLink struct {
+	Edge
+	start, end int
+	quoted     bool
+}
+
+// link parses a link and its optional text.
+func (p *mermaidParser) link() (*mermaidLink, bool) {
+	l := &mermaidLink{start: -1}
+	rest := p.rest()
+
+	if m := mermaidTextLinkRegex.FindStringSubmatchIndex(rest); m != nil {
+		l.setArrow(rest[m[2]:m[3]], rest[m[4]:m[5]]+rest[m[8]:m[9]])
+		l.start, l.end, l.quoted = trimQuotes(rest, m[6], m[7])
+		l.Label = unescapeLabel(rest[l.start:l.end])
+		l.start += p.pos
+		l.end += p.pos
+		p.pos += m[1]
+		return l, true
+	}
+
+	m := mermaidLinkRegex.FindStringSubmatchIndex(rest)
+	if m == nil {
+		return nil, false
+	}
+	l.setArrow(rest[m[2]:m[3]], rest[m[4]:m[7]])
+	p.pos += m[1]
+
+	p.skipSpace()
+	if p.pos < p.end && p.src[p.pos] == '|' {
+		i := strings.IndexByte(p.rest()[1:], '|')
+		if i < 0 {
+			return nil, false
+		}
+		start, end, quoted := trimQuotes(p.src, p.pos+1, p.pos+1+i)
+		l.Label = unescapeLabel(p.src[start:end])
+		l.start, l.end, l.quoted = start, end, quoted
+		p.pos += i + 2
+	}
+	return l, true
+}
+
+func (l *mermaidLink) setArrow(start, arrow string) {
+	l.ArrowStart = start == "<"
+	l.ArrowEnd = strings.HasSuffix(arrow, ">")
+	l.Dashed = strings.Contains(arrow, ".")
+}
+
+// nodeGroup parses one or more nodes joined by "&".
+func (p *mermaidParser) nodeGroup() ([]*Node, error) {
+	var nodes []*Node
+	for {
+		n, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+
+		p.skipSpace()
+		if p.pos >= p.end || p.src[p.pos] != '&' {
+			return nodes, nil
+		}
+		p.pos++
+	}
+}
+
+// node parses a node ID with an optional shaped label, e.g. A["Label"].
+func (p *mermaidParser) node() (*Node, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < p.end {
+		r := rune(p.src[p.pos])
+		if r >= 0x80 {
+			r = []rune(p.src[p.pos:p.end])[0]
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && !(r == '-' && !strings.HasPrefix(p.rest(), "--") && !strings.HasPrefix(p.rest(), "-.")) {
+			break
+		}
+		p.pos += len(string(r))
+	}
+	if p.pos == start {
+		return nil, ErrSyntax
+	}
+	n := p.b.node(p.src[start:p.pos])
+
+	rest := p.rest()
+	for _, s := range mermaidShapes {
+		if !strings.HasPrefix(rest, s.open) {
+			continue
+		}
+		i := strings.Index(rest[len(s.open):], s.close)
+		if i < 0 {
+			return nil, ErrSyntax
+		}
+		ls, le, quoted := trimQuotes(rest, len(s.open), len(s.open)+i)
+		n.Label = unescapeLabel(rest[ls:le])
+		n.Shape = s.shape
+		p.b.span(p.pos+ls, p.pos+le, quoted)
+		p.pos += len(s.open) + i + len(s.close)
+		break
+	}
+
+	if strings.HasPrefix(p.rest(), ":::") {
+		p.pos += 3
+		for p.pos < p.end && !strings.ContainsRune(" \t;&-=.<", rune(p.src[p.pos])) {
+			p.pos++
+		}
+	}
+	return n, nil
+}
+
+// trimQuotes returns the range of s[start:end] without surrounding whitespace and double quotes.
+func trimQuotes(s string, start, end int) (int, int, bool) {
+	for start < end && (s[start] == ' ' || s[start] == '\t') {
+		start++
+	}
+	for end > start && (s[end-1] == ' ' || s[end-1] == '\t') {
+		end--
+	}
+	if end-start >= 2 && s[start] == '"' && s[end-1] == '"' {
+		return start + 1, end - 1, true
+	}
+	return start, end, false
+}
+
+var labelReplacer = strings.NewReplacer(
+	"<br>", "\n", "<br/>", "\n", "<br />", "\n",
+	`\n`, "\n", `\l`, "\n", `\r`, "\n",
+	`\"`, `"`, "#quot;", `"`,
+)
+
+// unescapeLabel returns the text of a label, with line breaks as newlines.
+func unescapeLabel(label string) string {
+	return labelReplacer.Replace(label)
+}
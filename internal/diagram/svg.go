@@ -0,0 +1,154 @@
+package diagram
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// renderMermaid renders a Mermaid flowchart to an SVG element whose ids start with id.
+// Colors follow the surrounding text; the classes of the elements allow styling with CSS.
+func renderMermaid(source, id string) (string, error) {
+	g, _, err := parseMermaid(source)
+	if err != nil {
+		return "", err
+	}
+	if len(g.Nodes) == 0 {
+		return "", ErrSyntax
+	}
+	return writeSVG(g, layoutGraph(g), id), nil
+}
+
+// svgIDRef matches the ids of SVG elements and the references to them.
+var svgIDRef = regexp.MustCompile(`\b(id="|href="#|url\(#)`)
+
+// scopeIDs prefixes the ids of an SVG element with id, so that they are unique in the page.
+func scopeIDs(svg, id string) string {
+	return svgIDRef.ReplaceAllString(svg, "${1}"+id+"-")
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+func writeSVG(g *Graph, l *layout, id string) string {
+	var b strings.Builder
+
+	var names []string
+	for _, n := range g.Nodes {
+		names = append(names, strings.ReplaceAll(n.Label, "\n", " "))
+	}
+
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" class="diagram" viewBox="0 0 ` + num(l.width) + ` ` + num(l.height) + `"`)
+	b.WriteString(` width="` + num(l.width) + `" height="` + num(l.height) + `" role="img" aria-label="` + html.EscapeString(strings.Join(names, ", ")) + `"`)
+	b.WriteString(` font-size="` + num(fontSize) + `" fill="none" stroke="currentColor" stroke-width="1.5">`)
+
+	b.WriteString(`<defs><marker id="` + id + `-arrow" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse">`)
+	b.WriteString(`<path d="M0,0L10,5L0,10z" fill="currentColor" stroke="none"/></marker></defs>`)
+
+	b.WriteString(`<g class="diagram-edges">`)
+	for _, r := range l.routes {
+		writeEdge(&b, r, id)
+	}
+	b.WriteString(`</g><g class="diagram-nodes">`)
+	for _, v := range l.vertices {
+		if v.node != nil {
+			writeNode(&b, v)
+		}
+	}
+	b.WriteString(`</g></svg>`)
+	return b.String()
+}
+
+func writeEdge(b *strings.Builder, r *route, id string) {
+	e := r.edge
+	class := "diagram-edge"
+	if e.Dashed {
+		class += " diagram-edge-dashed"
+	}
+
+	b.WriteString(`<path class="` + class + `" d="M` + num(r.points[0].X) + "," + num(r.points[0].Y))
+	if len(r.points) == 4 && e.From == e.To {
+		b.WriteString("C")
+		for i, p := range r.points[1:] {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(num(p.X) + "," + num(p.Y))
+		}
+	} else {
+		for _, p := range r.points[1:] {
+			b.WriteString("L" + num(p.X) + "," + num(p.Y))
+		}
+	}
+	b.WriteString(`"`)
+	if e.Dashed {
+		b.WriteString(` stroke-dasharray="5 4"`)
+	}
+	if e.ArrowStart {
+		b.WriteString(` marker-start="url(#` + id + `-arrow)"`)
+	}
+	if e.ArrowEnd {
+		b.WriteString(` marker-end="url(#` + id + `-arrow)"`)
+	}
+	b.WriteString(`/>`)
+
+	if e.Label != "" {
+		lines := labelLines(e.Label)
+		w, h := labelSize(lines)
+		c := r.labelCenter(w, h)
+		b.WriteString(`<g class="diagram-edge-label">`)
+		b.WriteString(`<rect x="` + num(c.X-w/2-4) + `" y="` + num(c.Y-h/2) + `" width="` + num(w+8) + `" height="` + num(h) + `" stroke="none"/>`)
+		writeText(b, lines, c.X, c.Y)
+		b.WriteString(`</g>`)
+	}
+}
+
+func writeNode(b *strings.Builder, v *vertex) {
+	b.WriteString(`<g class="diagram-node">`)
+	x, y, w, h := num(v.x-v.w/2), num(v.y-v.h/2), num(v.w), num(v.h)
+	switch v.node.Shape {
+	case ShapeRect:
+		b.WriteString(`<rect x="` + x + `" y="` + y + `" width="` + w + `" height="` + h + `"/>`)
+	case ShapeRounded:
+		b.WriteString(`<rect x="` + x + `" y="` + y + `" width="` + w + `" height="` + h + `" rx="` + num(min(v.h/2, 12)) + `"/>`)
+	case ShapeEllipse, ShapeCircle:
+		b.WriteString(`<ellipse cx="` + num(v.x) + `" cy="` + num(v.y) + `" rx="` + num(v.w/2) + `" ry="` + num(v.h/2) + `"/>`)
+	case ShapeDiamond:
+		b.WriteString(`<polygon points="` + num(v.x) + "," + num(v.y-v.h/2) + " " + num(v.x+v.w/2) + "," + num(v.y) + " " +
+			num(v.x) + "," + num(v.y+v.h/2) + " " + num(v.x-v.w/2) + "," + num(v.y) + `"/>`)
+	}
+	writeText(b, v.lines, v.x, v.y)
+	b.WriteString(`</g>`)
+}
+
+// writeText writes lines of text centered on (x, y).
+func writeText(b *strings.Builder, lines []string, x, y float64) {
+	b.WriteString(`<text text-anchor="middle" dominant-baseline="central" fill="currentColor" stroke="none">`)
+	top := y - float64(len(lines)-1)*lineHeight/2
+	for i, line := range lines {
+		b.WriteString(`<tspan x="` + num(x) + `" y="` + num(top+float64(i)*lineHeight) + `">` + html.EscapeString(line) + `</tspan>`)
+	}
+	b.WriteString(`</text>`)
+}
+
+// midpoint returns the point halfway along a polyline.
+func midpoint(points []point) point {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+	}
+
+	half := total / 2
+	for i := 1; i < len(points); i++ {
+		d := math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+		if d >= half && d > 0 {
+			t := half / d
+			return point{points[i-1].X + t*(points[i].X-points[i-1].X), points[i-1].Y + t*(points[i].Y-points[i-1].Y)}
+		}
+		half -= d
+	}
+	return points[0]
+}
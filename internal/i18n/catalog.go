@@ -0,0 +1,136 @@
+// Package i18n provides the message catalogs of the user interface and the
+// locale-aware formatting of dates.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"gosuda.org/website/internal/types"
+)
+
+var ErrInvalidCatalog = errors.New("invalid message catalog")
+
+// placeholderPattern matches the placeholders of messages, like {minutes}.
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// Catalog holds the messages of the user interface in every language, keyed by
+// message key. The English catalog defines the keys; messages missing from other
+// languages fall back to English.
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[types.Lang]map[string]string
+}
+
+// Load reads the catalogs from the YAML files in dir, one per language, named after
+// the language code. The English catalog must exist.
+func Load(dir string) (*Catalog, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{messages: make(map[types.Lang]map[string]string)}
+	for _, file := range files {
+		lang := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		if !slices.Contains(types.SupportedLanguages, lang) {
+			return nil, fmt.Errorf("%w: %s: unsupported language %q", ErrInvalidCatalog, file, lang)
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		err = yaml.Unmarshal(data, &messages)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidCatalog, file, err)
+		}
+		c.messages[lang] = messages
+	}
+
+	en, ok := c.messages[types.LangEnglish]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s.yaml is missing", ErrInvalidCatalog, dir, types.LangEnglish)
+	}
+	for lang, messages := range c.messages {
+		for key, text := range messages {
+			source, ok := en[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: unknown key %q", ErrInvalidCatalog, lang, key)
+			}
+			if !SamePlaceholders(source, text) {
+				return nil, fmt.Errorf("%w: %s: %s: placeholders differ from English", ErrInvalidCatalog, lang, key)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Message returns the message of key in lang, replacing its placeholders by the
+// given pairs of placeholder and value. Messages missing from lang are taken from
+// English, and unknown keys are returned as is.
+func (c *Catalog) Message(lang types.Lang, key string, oldnew ...string) string {
+	c.mu.RLock()
+	text, ok := c.messages[lang][key]
+	if !ok {
+		text, ok = c.messages[types.LangEnglish][key]
+	}
+	c.mu.RUnlock()
+	if !ok {
+		return key
+	}
+	if len(oldnew) > 0 {
+		text = strings.NewReplacer(oldnew...).Replace(text)
+	}
+	return text
+}
+
+// Source returns the English message of key.
+func (c *Catalog) Source(key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.messages[types.LangEnglish][key]
+}
+
+// Missing returns the sorted keys of the English messages that lang does not have.
+func (c *Catalog) Missing(lang types.Lang) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var keys []string
+	for key := range c.messages[types.LangEnglish] {
+		if _, ok := c.messages[lang][key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set sets the message of key in lang, as done for machine translations.
+func (c *Catalog) Set(lang types.Lang, key, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[lang] == nil {
+		c.messages[lang] = make(map[string]string)
+	}
+	c.messages[lang][key] = text
+}
+
+// SamePlaceholders reports whether the messages have the same placeholders.
+func SamePlaceholders(a, b string) bool {
+	pa := placeholderPattern.FindAllString(a, -1)
+	pb := placeholderPattern.FindAllString(b, -1)
+	sort.Strings(pa)
+	sort.Strings(pb)
+	return slices.Equal(pa, pb)
+}
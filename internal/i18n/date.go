@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gosuda.org/website/internal/types"
+)
+
+// dateFormat is the long date format of a language. The layout contains the
+// placeholders {day}, {month} and {year}; months holds the month names in the form
+// used in dates, or nil for numeric months.
+type dateFormat struct {
+	layout string
+	months *[12]string
+}
+
+var (
+	monthsEnglish    = [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	monthsSpanish    = [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"}
+	monthsGerman     = [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+	monthsRussian    = [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"}
+	monthsFrench     = [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+	monthsDutch      = [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"}
+	monthsItalian    = [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"}
+	monthsIndonesian = [12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+	monthsPortuguese = [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"}
+	monthsSwedish    = [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"}
+	monthsCzech      = [12]string{"ledna", "února", "března", "dubna", "května", "června", "července", "srpna", "září", "října", "listopadu", "prosince"}
+	monthsSlovak     = [12]string{"januára", "februára", "marca", "apríla", "mája", "júna", "júla", "augusta", "septembra", "októbra", "novembra", "decembra"}
+	monthsPolish     = [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"}
+	monthsRomanian   = [12]string{"ianuarie", "februarie", "martie", "aprilie", "mai", "iunie", "iulie", "august", "septembrie", "octombrie", "noiembrie", "decembrie"}
+	monthsHungarian  = [12]string{"január", "február", "március", "április", "május", "június", "július", "augusztus", "szeptember", "október", "november", "december"}
+	monthsFinnish    = [12]string{"tammikuuta", "helmikuuta", "maaliskuuta", "huhtikuuta", "toukokuuta", "kesäkuuta", "heinäkuuta", "elokuuta", "syyskuuta", "lokakuuta", "marraskuuta", "joulukuuta"}
+	monthsTurkish    = [12]string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"}
+	monthsDanish     = [12]string{"januar", "februar", "marts", "april", "maj", "juni", "juli", "august", "september", "oktober", "november", "december"}
+	monthsNorwegian  = [12]string{"januar", "februar", "mars", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "desember"}
+	monthsBulgarian  = [12]string{"януари", "февруари", "март", "април", "май", "юни", "юли", "август", "септември", "октомври", "ноември", "декември"}
+)
+
+var dateFormats = map[types.Lang]dateFormat{
+	types.LangEnglish:    {"{month} {day}, {year}", &monthsEnglish},
+	types.LangSpanish:    {"{day} de {month} de {year}", &monthsSpanish},
+	types.LangChinese:    {"{year}年{month}月{day}日", nil},
+	types.LangKorean:     {"{year}년 {month}월 {day}일", nil},
+	types.LangJapanese:   {"{year}年{month}月{day}日", nil},
+	types.LangGerman:     {"{day}. {month} {year}", &monthsGerman},
+	types.LangRussian:    {"{day} {month} {year} г.", &monthsRussian},
+	types.LangFrench:     {"{day} {month} {year}", &monthsFrench},
+	types.LangDutch:      {"{day} {month} {year}", &monthsDutch},
+	types.LangItalian:    {"{day} {month} {year}", &monthsItalian},
+	types.LangIndonesian: {"{day} {month} {year}", &monthsIndonesian},
+	types.LangPortuguese: {"{day} de {month} de {year}", &monthsPortuguese},
+	types.LangSwedish:    {"{day} {month} {year}", &monthsSwedish},
+	types.LangCzech:      {"{day}. {month} {year}", &monthsCzech},
+	types.LangSlovak:     {"{day}. {month} {year}", &monthsSlovak},
+	types.LangPolish:     {"{day} {month} {year}", &monthsPolish},
+	types.LangRomanian:   {"{day} {month} {year}", &monthsRomanian},
+	types.LangHungarian:  {"{year}. {month} {day}.", &monthsHungarian},
+	types.LangFinnish:    {"{day}. {month} {year}", &monthsFinnish},
+	types.LangTurkish:    {"{day} {month} {year}", &monthsTurkish},
+	types.LangDanish:     {"{day}. {month} {year}", &monthsDanish},
+	types.LangNorwegian:  {"{day}. {month} {year}", &monthsNorwegian},
+	types.LangBulgarian:  {"{day} {month} {year} г.", &monthsBulgarian},
+}
+
+// FormatDate formats date in the long date format of lang, falling back to English.
+func FormatDate(lang types.Lang, date time.Time) string {
+	f, ok := dateFormats[lang]
+	if !ok {
+		f = dateFormats[types.LangEnglish]
+	}
+	month := strconv.Itoa(int(date.Month()))
+	if f.months != nil {
+		month = f.months[date.Month()-1]
+	}
+	return strings.NewReplacer(
+		"{day}", strconv.Itoa(date.Day()),
+		"{month}", month,
+		"{year}", strconv.Itoa(date.Year()),
+	).Replace(f.layout)
+}
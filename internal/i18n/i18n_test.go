@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gosuda.org/website/internal/types"
+)
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC)
+	for lang, want := range map[types.Lang]string{
+		types.LangEnglish:   "March 2, 2025",
+		types.LangKorean:    "2025년 3월 2일",
+		types.LangJapanese:  "2025年3月2日",
+		types.LangRussian:   "2 марта 2025 г.",
+		types.LangHungarian: "2025. március 2.",
+		"xx":                "March 2, 2025",
+	} {
+		if got := FormatDate(lang, date); got != want {
+			t.Errorf("FormatDate(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	c, err := Load("../../i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Message(types.LangKorean, "min_read", "{minutes}", "5"); got != "5분 분량" {
+		t.Errorf("Message(ko, min_read) = %q", got)
+	}
+	if got := c.Message(types.LangFinnish, "min_read", "{minutes}", "5"); got != "5 min read" {
+		t.Errorf("Message(fi, min_read) = %q, want the English message", got)
+	}
+	if got := c.Message(types.LangEnglish, "unknown"); got != "unknown" {
+		t.Errorf("Message(en, unknown) = %q", got)
+	}
+
+	missing := c.Missing(types.LangFinnish)
+	if len(missing) == 0 || missing[0] != "admonition_caution" {
+		t.Errorf("Missing(fi) = %q", missing)
+	}
+	c.Set(types.LangFinnish, "by", "Kirjoittanut")
+	if got := c.Message(types.LangFinnish, "by"); got != "Kirjoittanut" {
+		t.Errorf("Message(fi, by) = %q after Set", got)
+	}
+	if len(c.Missing(types.LangFinnish)) != len(missing)-1 {
+		t.Error("Set message is still missing")
+	}
+}
+
+func TestInvalidCatalog(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"no English":       {"ko.yaml": "by: 작성자\n"},
+		"unknown key":      {"en.yaml": "by: By\n", "ko.yaml": "from: 작성자\n"},
+		"placeholders":     {"en.yaml": "min_read: \"{minutes} min read\"\n", "ko.yaml": "min_read: 분 분량\n"},
+		"unknown language": {"en.yaml": "by: By\n", "xx.yaml": "by: By\n"},
+	} {
+		dir := t.TempDir()
+		for file, data := range files {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := Load(dir); !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("%s: Load = %v, want ErrInvalidCatalog", name, err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package imagemeta caches the dimensions of the images used by posts, so that
+// rendering never waits on the network.
+package imagemeta
+
+import (
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zeebo/blake3"
+	"gosuda.org/website/internal/types"
+)
+
+const (
+	// fetchTimeout bounds the download of a single external image.
+	fetchTimeout = 10 * time.Second
+	// maxImageSize is the largest external image downloaded.
+	maxImageSize = 32 << 20
+	// fetchWorkers is the number of external images downloaded in parallel.
+	fetchWorkers = 8
+)
+
+var ErrUnreachable = errors.New("image is unreachable")
+
+// Cache holds the dimensions of local and external images.
+//
+// Local images are read from the public directory and validated by their content hash.
+// External images are only downloaded by Prefetch; lookups never touch the network.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*types.ImageInfo
+	// checked holds the local images whose hash was validated during this build.
+	checked map[string]bool
+	// failed holds the images that could not be read during this build.
+	failed map[string]bool
+	// used holds the images looked up during this build.
+	used map[string]bool
+
+	publicDir string
+	offline   bool
+	client    *http.Client
+}
+
+// New returns a cache backed by entries, which is updated in place. In offline
+// mode, external images missing from entries are never downloaded.
+func New(entries map[string]*types.ImageInfo, publicDir string, offline bool) *Cache {
+	return &Cache{
+		entries:   entries,
+		checked:   make(map[string]bool),
+		failed:    make(map[string]bool),
+		used:      make(map[string]bool),
+		publicDir: publicDir,
+		offline:   offline,
+		client:    &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+// IsExternal reports whether src refers to an image on another host.
+func IsExternal(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")
+}
+
+// Size returns the dimensions of the image at src, or false if they are unknown.
+func (c *Cache) Size(src string) (int, int, bool) {
+	info, ok := c.Info(src)
+	if !ok {
+		return 0, 0, false
+	}
+	return info.Width, info.Height, true
+}
+
+// Info returns the metadata of the image at src, or false if it is unknown.
+func (c *Cache) Info(src string) (*types.ImageInfo, bool) {
+	if c == nil || strings.HasPrefix(src, "data:") {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	c.used[src] = true
+	info, ok := c.entries[src]
+	failed := c.failed[src]
+	c.mu.Unlock()
+	if failed {
+		return nil, false
+	}
+
+	if IsExternal(src) {
+		return info, ok
+	}
+
+	info, err := c.local(src)
+	if err != nil {
+		c.fail(src, err)
+		return nil, false
+	}
+	return info, true
+}
+
+// fail records an image that could not be read, reporting it once per build.
+// Images in formats without a decoder, e.g. SVG, are not reported.
+func (c *Cache) fail(src string, err error) {
+	c.mu.Lock()
+	c.failed[src] = true
+	c.mu.Unlock()
+
+	if errors.Is(err, image.ErrFormat) {
+		log.Debug().Str("src", src).Msg("unknown image format, rendering it without dimensions")
+		return
+	}
+	log.Warn().Err(err).Str("src", src).Msg("image is unreachable, rendering it without dimensions")
+}
+
+// Prune removes the images that were not used during this build.
+func (c *Cache) Prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for src := range c.entries {
+		if !c.used[src] {
+			delete(c.entries, src)
+		}
+	}
+}
+
+// local returns the metadata of a local image, reading it again if its content changed.
+func (c *Cache) local(src string) (*types.ImageInfo, error) {
+	c.mu.Lock()
+	info, ok := c.entries[src]
+	checked := c.checked[src]
+	c.mu.Unlock()
+	if ok && checked {
+		return info, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.publicDir, filepath.FromSlash(src)))
+	if err != nil {
+		return nil, err
+	}
+	hash := contentHash(data)
+	if !ok || info.Hash != hash {
+		info, err = decode(data, hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	c.entries[src] = info
+	c.checked[src] = true
+	c.mu.Unlock()
+	return info, nil
+}
+
+// Prefetch reads the images in sources that are missing from the cache, downloading
+// external images in parallel. Unreachable images are reported as warnings.
+func (c *Cache) Prefetch(ctx context.Context, sources []string) {
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for range fetchWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range queue {
+				info, err := c.fetch(ctx, src)
+				if err != nil {
+					c.fail(src, err)
+					continue
+				}
+				c.mu.Lock()
+				c.entries[src] = info
+				c.mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	skipped := 0
+	for _, src := range sources {
+		if seen[src] {
+			continue
+		}
+		seen[src] = true
+
+		if !IsExternal(src) {
+			c.Size(src)
+			continue
+		}
+
+		c.mu.Lock()
+		c.used[src] = true
+		_, ok := c.entries[src]
+		c.mu.Unlock()
+		if ok {
+			continue
+		}
+		if c.offline {
+			skipped++
+			continue
+		}
+		queue <- src
+	}
+	close(queue)
+	wg.Wait()
+
+	if skipped > 0 {
+		log.Warn().Int("count", skipped).Msg("offline mode, rendering uncached external images without dimensions")
+	}
+}
+
+func (c *Cache) fetch(ctx context.Context, src string) (*types.ImageInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrUnreachable, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+	return decode(data, contentHash(data))
+}
+
+func decode(data []byte, hash string) (*types.ImageInfo, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &types.ImageInfo{
+		Width:     cfg.Width,
+		Height:    cfg.Height,
+		Hash:      hash,
+		CheckedAt: time.Now().UTC(),
+	}, nil
+}
+
+func contentHash(data []byte) string {
+	sum := blake3.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
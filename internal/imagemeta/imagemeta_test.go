@@ -0,0 +1,72 @@
+package imagemeta
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gosuda.org/website/internal/types"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "a.png"), 3, 2)
+
+	entries := make(map[string]*types.ImageInfo)
+	w, h, ok := New(entries, dir, true).Size("/a.png")
+	if !ok || w != 3 || h != 2 {
+		t.Fatalf("Size = %d, %d, %v, want 3, 2, true", w, h, ok)
+	}
+
+	// A changed file is read again in the next build.
+	writePNG(t, filepath.Join(dir, "a.png"), 5, 4)
+	w, h, ok = New(entries, dir, true).Size("/a.png")
+	if !ok || w != 5 || h != 4 {
+		t.Fatalf("Size after change = %d, %d, %v, want 5, 4, true", w, h, ok)
+	}
+
+	if _, _, ok := New(entries, dir, true).Size("/missing.png"); ok {
+		t.Fatal("Size of a missing image succeeded")
+	}
+}
+
+func TestOffline(t *testing.T) {
+	const cached, uncached = "https://example.com/cached.png", "https://example.com/uncached.png"
+	entries := map[string]*types.ImageInfo{
+		cached:               {Width: 640, Height: 480},
+		"https://unused.png": {Width: 1, Height: 1},
+	}
+
+	c := New(entries, t.TempDir(), true)
+	c.Prefetch(context.Background(), []string{cached, uncached})
+
+	if w, h, ok := c.Size(cached); !ok || w != 640 || h != 480 {
+		t.Fatalf("Size(cached) = %d, %d, %v, want 640, 480, true", w, h, ok)
+	}
+	if _, _, ok := c.Size(uncached); ok {
+		t.Fatal("Size(uncached) succeeded in offline mode")
+	}
+
+	c.Prune()
+	if _, ok := entries["https://unused.png"]; ok {
+		t.Fatal("Prune kept an unused image")
+	}
+	if _, ok := entries[cached]; !ok {
+		t.Fatal("Prune removed a used image")
+	}
+}
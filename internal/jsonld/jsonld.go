@@ -0,0 +1,284 @@
+// Package jsonld builds schema.org structured data for the generated pages.
+package jsonld
+
+import (
+	"strings"
+	"time"
+)
+
+const Context = "https://schema.org"
+
+// MaxHeadline is the length of the longest headline search engines accept, in
+// characters.
+const MaxHeadline = 110
+
+// Graph is the top-level JSON-LD document embedded in a page.
+type Graph struct {
+	Context string `json:"@context"`
+	Graph   []any  `json:"@graph"`
+}
+
+// Ref references a node that is described elsewhere by its @id.
+type Ref struct {
+	ID string `json:"@id"`
+}
+
+type Organization struct {
+	Type   string       `json:"@type"`
+	ID     string       `json:"@id,omitempty"`
+	Name   string       `json:"name"`
+	URL    string       `json:"url"`
+	Logo   *ImageObject `json:"logo,omitempty"`
+	SameAs []string     `json:"sameAs,omitempty"`
+}
+
+type WebSite struct {
+	Type        string `json:"@type"`
+	ID          string `json:"@id,omitempty"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+	InLanguage  string `json:"inLanguage,omitempty"`
+	Publisher   *Ref   `json:"publisher,omitempty"`
+}
+
+type Person struct {
+	Type   string   `json:"@type"`
+	Name   string   `json:"name"`
+	URL    string   `json:"url,omitempty"`
+	Image  string   `json:"image,omitempty"`
+	SameAs []string `json:"sameAs,omitempty"`
+}
+
+// CreativeWork identifies a work described on another page, such as another language
+// version of a post.
+type CreativeWork struct {
+	Type string `json:"@type"`
+	ID   string `json:"@id"`
+	URL  string `json:"url"`
+}
+
+type ImageObject struct {
+	Type   string `json:"@type"`
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+type BlogPosting struct {
+	Type              string          `json:"@type"`
+	ID                string          `json:"@id"`
+	URL               string          `json:"url"`
+	MainEntityOfPage  string          `json:"mainEntityOfPage"`
+	Headline          string          `json:"headline"`
+	Description       string          `json:"description,omitempty"`
+	Author            []*Person       `json:"author"`
+	DatePublished     string          `json:"datePublished"`
+	DateModified      string          `json:"dateModified"`
+	InLanguage        string          `json:"inLanguage"`
+	Image             *ImageObject    `json:"image,omitempty"`
+	Keywords          []string        `json:"keywords,omitempty"`
+	WordCount         int             `json:"wordCount,omitempty"`
+	Publisher         *Ref            `json:"publisher,omitempty"`
+	TranslationOfWork *CreativeWork   `json:"translationOfWork,omitempty"`
+	WorkTranslation   []*CreativeWork `json:"workTranslation,omitempty"`
+	About             *Ref            `json:"about,omitempty"`
+}
+
+type SoftwareSourceCode struct {
+	Type                string `json:"@type"`
+	ID                  string `json:"@id"`
+	Name                string `json:"name"`
+	URL                 string `json:"url"`
+	Description         string `json:"description,omitempty"`
+	CodeRepository      string `json:"codeRepository"`
+	ProgrammingLanguage string `json:"programmingLanguage"`
+	Publisher           *Ref   `json:"publisher,omitempty"`
+}
+
+type BreadcrumbList struct {
+	Type            string      `json:"@type"`
+	ItemListElement []*ListItem `json:"itemListElement"`
+}
+
+type ListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Item     string `json:"item"`
+}
+
+// Site describes the organization publishing the pages.
+type Site struct {
+	Name        string
+	URL         string
+	Description string
+	Logo        string
+	SameAs      []string
+}
+
+// OrganizationID returns the @id of the publishing organization.
+func (s *Site) OrganizationID() string {
+	return strings.TrimSuffix(s.URL, "/") + "/#organization"
+}
+
+func (s *Site) organization() *Organization {
+	o := &Organization{
+		Type:   "Organization",
+		ID:     s.OrganizationID(),
+		Name:   s.Name,
+		URL:    s.URL,
+		SameAs: s.SameAs,
+	}
+	if s.Logo != "" {
+		o.Logo = &ImageObject{Type: "ImageObject", URL: s.Logo}
+	}
+	return o
+}
+
+// Crumb is a single level of a breadcrumb trail.
+type Crumb struct {
+	Name string
+	URL  string
+}
+
+// Article holds the page data of a post in one language.
+type Article struct {
+	URL         string
+	Headline    string
+	Description string
+	Language    string
+	Authors     []*Person
+	Published   time.Time
+	Modified    time.Time
+	Keywords    []string
+	WordCount   int
+
+	Image       string
+	ImageWidth  int
+	ImageHeight int
+
+	// TranslationOf is the URL of the main language version when the article is a translation.
+	TranslationOf string
+	// Translations are the URLs of the translated versions when the article is the main version.
+	Translations []string
+
+	// GoPackage and GoRepoURL describe the Go module documented by the article, if any.
+	GoPackage string
+	GoRepoURL string
+
+	Breadcrumbs []Crumb
+}
+
+func articleID(url string) string {
+	return url + "#article"
+}
+
+// article returns the article at url, which is described on its own page.
+func article(url string) *CreativeWork {
+	return &CreativeWork{Type: "CreativeWork", ID: articleID(url), URL: url}
+}
+
+// breadcrumbList returns the breadcrumb trail of the crumbs, from the home page down.
+func breadcrumbList(crumbs []Crumb) *BreadcrumbList {
+	list := &BreadcrumbList{Type: "BreadcrumbList"}
+	for i, crumb := range crumbs {
+		list.ItemListElement = append(list.ItemListElement, &ListItem{
+			Type:     "ListItem",
+			Position: i + 1,
+			Name:     crumb.Name,
+			Item:     crumb.URL,
+		})
+	}
+	return list
+}
+
+// IndexGraph returns the structured data of the home page in lang, whose breadcrumb
+// trail is the home page alone.
+func IndexGraph(site *Site, url string, lang string) *Graph {
+	return &Graph{
+		Context: Context,
+		Graph: []any{
+			site.organization(),
+			&WebSite{
+				Type:        "WebSite",
+				ID:          url + "#website",
+				Name:        site.Name,
+				URL:         url,
+				Description: site.Description,
+				InLanguage:  lang,
+				Publisher:   &Ref{ID: site.OrganizationID()},
+			},
+			breadcrumbList([]Crumb{{Name: site.Name, URL: url}}),
+		},
+	}
+}
+
+// PostGraph returns the structured data of a post page.
+func PostGraph(site *Site, a *Article) *Graph {
+	posting := &BlogPosting{
+		Type:             "BlogPosting",
+		ID:               articleID(a.URL),
+		URL:              a.URL,
+		MainEntityOfPage: a.URL,
+		Headline:         a.Headline,
+		Description:      a.Description,
+		DatePublished:    a.Published.UTC().Format(time.RFC3339),
+		DateModified:     a.Modified.UTC().Format(time.RFC3339),
+		InLanguage:       a.Language,
+		Keywords:         a.Keywords,
+		WordCount:        a.WordCount,
+		Publisher:        &Ref{ID: site.OrganizationID()},
+	}
+
+	for _, author := range a.Authors {
+		person := *author
+		person.Type = "Person"
+		posting.Author = append(posting.Author, &person)
+	}
+	if len(posting.Author) == 0 {
+		posting.Author = append(posting.Author, &Person{Type: "Person", Name: site.Name})
+	}
+
+	if a.Image != "" {
+		posting.Image = &ImageObject{
+			Type:   "ImageObject",
+			URL:    a.Image,
+			Width:  a.ImageWidth,
+			Height: a.ImageHeight,
+		}
+	}
+
+	if a.TranslationOf != "" {
+		posting.TranslationOfWork = article(a.TranslationOf)
+	}
+	for _, url := range a.Translations {
+		posting.WorkTranslation = append(posting.WorkTranslation, article(url))
+	}
+
+	g := &Graph{
+		Context: Context,
+		Graph:   []any{site.organization(), posting},
+	}
+
+	if a.GoPackage != "" && a.GoRepoURL != "" {
+		code := &SoftwareSourceCode{
+			Type:                "SoftwareSourceCode",
+			ID:                  a.URL + "#code",
+			Name:                a.GoPackage,
+			URL:                 a.URL,
+			Description:         a.Description,
+			CodeRepository:      strings.TrimSuffix(a.GoRepoURL, ".git"),
+			ProgrammingLanguage: "Go",
+			Publisher:           &Ref{ID: site.OrganizationID()},
+		}
+		posting.About = &Ref{ID: code.ID}
+		g.Graph = append(g.Graph, code)
+	}
+
+	if len(a.Breadcrumbs) > 0 {
+		g.Graph = append(g.Graph, breadcrumbList(a.Breadcrumbs))
+	}
+
+	return g
+}
@@ -0,0 +1,154 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSite = &Site{
+	Name:        "GoSuda",
+	URL:         "https://gosuda.org/",
+	Description: "GoSuda is an open source working group.",
+	Logo:        "https://gosuda.org/assets/gosuda.png",
+	SameAs:      []string{"https://github.com/gosuda"},
+}
+
+func assertValid(t *testing.T, g *Graph) {
+	t.Helper()
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIndexGraph(t *testing.T) {
+	g := IndexGraph(testSite, "https://gosuda.org/ko/", "ko")
+	assertValid(t, g)
+
+	if _, ok := g.Graph[0].(*Organization); !ok {
+		t.Errorf("expected Organization node first, got %T", g.Graph[0])
+	}
+	list, ok := g.Graph[len(g.Graph)-1].(*BreadcrumbList)
+	if !ok {
+		t.Fatalf("expected BreadcrumbList node last, got %T", g.Graph[len(g.Graph)-1])
+	}
+	if len(list.ItemListElement) != 1 || list.ItemListElement[0].Item != "https://gosuda.org/ko/" || list.ItemListElement[0].Name != testSite.Name {
+		t.Errorf("expected the home page as the only crumb, got %+v", list.ItemListElement)
+	}
+}
+
+func TestPostGraph(t *testing.T) {
+	published := time.Date(2025, 11, 3, 7, 34, 17, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		article *Article
+		types   []string
+	}{
+		{
+			name: "Main language post",
+			article: &Article{
+				URL:         "https://gosuda.org/blog/posts/go-for-ai",
+				Headline:    "Go is the ideal language for AI apps",
+				Description: "Go is ideal for AI development.",
+				Language:    "en",
+				Authors: []*Person{{
+					Name:   "Lemon Mint",
+					URL:    "https://gosuda.org/authors/lemonmint/",
+					Image:  "https://github.com/lemon-mint.png",
+					SameAs: []string{"https://github.com/lemon-mint"},
+				}},
+				Published:    published,
+				Modified:     published.Add(time.Hour),
+				Image:        "https://gosuda.org/assets/id_en.png",
+				ImageWidth:   1150,
+				ImageHeight:  630,
+				Translations: []string{"https://gosuda.org/ko/blog/posts/go-for-ai"},
+				Breadcrumbs: []Crumb{
+					{Name: "GoSuda", URL: "https://gosuda.org/"},
+					{Name: "Go is the ideal language for AI apps", URL: "https://gosuda.org/blog/posts/go-for-ai"},
+				},
+			},
+			types: []string{"Organization", "BlogPosting", "BreadcrumbList"},
+		},
+		{
+			name: "Translated package post",
+			article: &Article{
+				URL:           "https://gosuda.org/ja/portal",
+				Headline:      "Portal",
+				Language:      "ja",
+				Published:     published,
+				Modified:      published,
+				Image:         "https://gosuda.org/assets/id_ja.png",
+				TranslationOf: "https://gosuda.org/ko/portal",
+				GoPackage:     "gosuda.org/portal",
+				GoRepoURL:     "https://github.com/gosuda/portal.git",
+			},
+			types: []string{"Organization", "BlogPosting", "SoftwareSourceCode"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := PostGraph(testSite, tc.article)
+			assertValid(t, g)
+
+			if len(g.Graph) != len(tc.types) {
+				t.Fatalf("expected %d nodes, got %d", len(tc.types), len(g.Graph))
+			}
+			for i, node := range g.Graph {
+				data, _ := json.Marshal(node)
+				if !strings.Contains(string(data), `"@type":"`+tc.types[i]+`"`) {
+					t.Errorf("node %d: expected @type %s, got %s", i, tc.types[i], data)
+				}
+			}
+		})
+	}
+}
+
+func TestPostGraphCodeRepository(t *testing.T) {
+	g := PostGraph(testSite, &Article{
+		URL:       "https://gosuda.org/portal",
+		Headline:  "Portal",
+		Language:  "en",
+		GoPackage: "gosuda.org/portal",
+		GoRepoURL: "https://github.com/gosuda/portal.git",
+	})
+	code, ok := g.Graph[len(g.Graph)-1].(*SoftwareSourceCode)
+	if !ok {
+		t.Fatalf("expected SoftwareSourceCode node, got %T", g.Graph[len(g.Graph)-1])
+	}
+	if code.CodeRepository != "https://github.com/gosuda/portal" {
+		t.Errorf("expected repository without .git suffix, got %s", code.CodeRepository)
+	}
+}
+
+func TestValidateRejectsInvalidGraph(t *testing.T) {
+	g := PostGraph(testSite, &Article{
+		URL:       "/relative",
+		Headline:  strings.Repeat("a", MaxHeadline+1),
+		Language:  "en",
+		Published: time.Now(),
+		Modified:  time.Now(),
+	})
+	err := g.Validate()
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Validate = %v, want ErrInvalid", err)
+	}
+	for _, want := range []string{"missing required property image", "is not an absolute URL", "headline is longer than"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected an error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateRejectsUnresolvedReference(t *testing.T) {
+	g := IndexGraph(testSite, "https://gosuda.org/", "en")
+	g.Graph[1].(*WebSite).Publisher = &Ref{ID: "https://gosuda.org/ko/blog/posts/go-for-ai#article"}
+	err := g.Validate()
+	if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), "unresolved reference") {
+		t.Errorf("Validate = %v, want an unresolved reference", err)
+	}
+}
@@ -0,0 +1,180 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalid = errors.New("invalid structured data")
+
+// typeSchema lists the properties a type requires, and the properties that must be
+// absolute URLs, RFC 3339 dates or at most a number of characters long.
+type typeSchema struct {
+	Required  []string
+	URLs      []string
+	Dates     []string
+	MaxLength map[string]int
+}
+
+// schema follows the structured data guidelines of search engines for the types the
+// pages use.
+var schema = map[string]*typeSchema{
+	"Organization": {
+		Required: []string{"@id", "name", "url"},
+		URLs:     []string{"url"},
+	},
+	"WebSite": {
+		Required: []string{"@id", "name", "url", "publisher"},
+		URLs:     []string{"url"},
+	},
+	"Person": {
+		Required: []string{"name"},
+		URLs:     []string{"url", "image"},
+	},
+	"ImageObject": {
+		Required: []string{"url"},
+		URLs:     []string{"url"},
+	},
+	"CreativeWork": {
+		Required: []string{"@id", "url"},
+		URLs:     []string{"url"},
+	},
+	"BlogPosting": {
+		Required:  []string{"@id", "url", "mainEntityOfPage", "headline", "author", "datePublished", "dateModified", "inLanguage", "image", "publisher"},
+		URLs:      []string{"url", "mainEntityOfPage"},
+		Dates:     []string{"datePublished", "dateModified"},
+		MaxLength: map[string]int{"headline": MaxHeadline},
+	},
+	"SoftwareSourceCode": {
+		Required: []string{"@id", "name", "url", "codeRepository", "programmingLanguage"},
+		URLs:     []string{"url", "codeRepository"},
+	},
+	"BreadcrumbList": {
+		Required: []string{"itemListElement"},
+	},
+	"ListItem": {
+		Required: []string{"position", "name", "item"},
+		URLs:     []string{"item"},
+	},
+}
+
+// Validate checks every typed node of the graph against the schema of its type, and
+// that every reference resolves to a node of the graph.
+func (g *Graph) Validate() error {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if doc["@context"] != Context {
+		errs = append(errs, fmt.Errorf("unexpected @context %v", doc["@context"]))
+	}
+
+	ids := make(map[string]bool)
+	var refs []string
+
+	var walk func(path string, v any)
+	walk = func(path string, v any) {
+		switch v := v.(type) {
+		case []any:
+			for i, item := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		case map[string]any:
+			typ, typed := v["@type"].(string)
+			if !typed {
+				if id, ok := v["@id"].(string); ok && len(v) == 1 {
+					refs = append(refs, id)
+					return
+				}
+				errs = append(errs, fmt.Errorf("%s: node without @type", path))
+				return
+			}
+
+			if id, ok := v["@id"].(string); ok {
+				if ids[id] {
+					errs = append(errs, fmt.Errorf("%s: duplicate @id %s", path, id))
+				}
+				ids[id] = true
+			}
+
+			s, ok := schema[typ]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown @type %s", path, typ))
+				return
+			}
+			for _, prop := range s.Required {
+				if isEmpty(v[prop]) {
+					errs = append(errs, fmt.Errorf("%s: %s is missing required property %s", path, typ, prop))
+				}
+			}
+			for _, prop := range s.URLs {
+				if str, ok := v[prop].(string); ok {
+					u, err := url.Parse(str)
+					if err != nil || !u.IsAbs() {
+						errs = append(errs, fmt.Errorf("%s: %s.%s is not an absolute URL: %q", path, typ, prop, str))
+					}
+				}
+			}
+			for _, prop := range s.Dates {
+				if str, ok := v[prop].(string); ok {
+					if _, err := time.Parse(time.RFC3339, str); err != nil {
+						errs = append(errs, fmt.Errorf("%s: %s.%s is not an ISO 8601 date: %q", path, typ, prop, str))
+					}
+				}
+			}
+			for prop, max := range s.MaxLength {
+				if str, ok := v[prop].(string); ok && utf8.RuneCountInString(str) > max {
+					errs = append(errs, fmt.Errorf("%s: %s.%s is longer than %d characters", path, typ, prop, max))
+				}
+			}
+
+			for k, child := range v {
+				if !strings.HasPrefix(k, "@") {
+					walk(path+"."+k, child)
+				}
+			}
+		}
+	}
+
+	graph, ok := doc["@graph"].([]any)
+	if !ok {
+		errs = append(errs, errors.New("missing @graph"))
+	}
+	walk("@graph", graph)
+
+	for _, ref := range refs {
+		if !ids[ref] {
+			errs = append(errs, fmt.Errorf("unresolved reference %s", ref))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalid, errors.Join(errs...))
+	}
+	return nil
+}
+
+func isEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+// Package llmrouter routes LLM calls to an ordered list of backends, falling back to
+// the next backend when one fails, and keeps backends that keep failing out of the way.
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lemon-mint/coord/llm"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrNoBackend = errors.New("no backend succeeded")
+
+// A backend that fails is skipped for cooldown, doubled on each consecutive failure up
+// to maxCooldown.
+const (
+	cooldown    = 30 * time.Second
+	maxCooldown = 10 * time.Minute
+)
+
+// now is the clock of the health of backends, replaced in tests.
+var now = time.Now
+
+// Stats count the calls to a backend.
+type Stats struct {
+	Calls    int
+	Failures int
+}
+
+// Backend is a model calls can be routed to. Its health is shared by the routers it
+// is part of.
+type Backend struct {
+	name  string
+	model llm.Model
+
+	mu sync.Mutex
+	// failures counts the consecutive failures, and until is the end of the cooldown
+	// they caused.
+	failures int
+	until    time.Time
+	stats    Stats
+}
+
+// NewBackend returns a healthy backend of the model.
+func NewBackend(name string, model llm.Model) *Backend {
+	return &Backend{name: name, model: model}
+}
+
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Stats returns the calls made to the backend so far.
+func (b *Backend) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// healthy reports whether the backend is not cooling down.
+func (b *Backend) healthy() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now().Before(b.until)
+}
+
+// record updates the health of the backend with the result of a call.
+func (b *Backend) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Calls++
+	if err == nil {
+		b.failures = 0
+		b.until = time.Time{}
+		return
+	}
+	b.stats.Failures++
+	b.failures++
+	d := maxCooldown
+	if b.failures <= 5 {
+		d = min(cooldown<<(b.failures-1), maxCooldown)
+	}
+	b.until = now().Add(d)
+}
+
+// generate calls the model, giving up after timeout if it is positive. The response is
+// complete when generate returns.
+func (b *Backend) generate(ctx context.Context, timeout time.Duration, chat *llm.ChatContext, input *llm.Content) (*llm.StreamContent, error) {
+	callCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	resp := b.model.GenerateStream(callCtx, chat, input)
+	if resp == nil {
+		b.record(llm.ErrNoResponse)
+		return nil, llm.ErrNoResponse
+	}
+	// Models that do not watch the context would hang the call past its timeout.
+	done := make(chan error, 1)
+	go func() {
+		done <- resp.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-callCtx.Done():
+		err = callCtx.Err()
+	}
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up, which says nothing about the backend.
+		return nil, ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	b.record(err)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Router is a model that calls its backends in order until one succeeds. Backends that
+// are cooling down are tried last.
+type Router struct {
+	backends []*Backend
+	timeout  time.Duration
+}
+
+// New returns a router of the backends. A call to a backend fails after timeout, unless
+// it is zero.
+func New(timeout time.Duration, backends ...*Backend) *Router {
+	return &Router{backends: backends, timeout: timeout}
+}
+
+// order returns the backends in the order they are tried.
+func (r *Router) order() []*Backend {
+	var healthy, cooling []*Backend
+	for _, b := range r.backends {
+		if b.healthy() {
+			healthy = append(healthy, b)
+		} else {
+			cooling = append(cooling, b)
+		}
+	}
+	return append(healthy, cooling...)
+}
+
+// GenerateStream generates the response with the first backend that succeeds. The
+// response is streamed once it is complete, so that a failing backend never leaves
+// part of its response in the stream.
+func (r *Router) GenerateStream(ctx context.Context, chat *llm.ChatContext, input *llm.Content) *llm.StreamContent {
+	stream := make(chan llm.Segment)
+	out := &llm.StreamContent{Stream: stream}
+
+	go func() {
+		defer close(stream)
+
+		resp, err := r.generate(ctx, chat, input)
+		if err != nil {
+			out.Err = err
+			out.Content = &llm.Content{Role: llm.RoleModel}
+			out.FinishReason = llm.FinishReasonError
+			return
+		}
+		out.Content = resp.Content
+		out.UsageData = resp.UsageData
+		out.FinishReason = resp.FinishReason
+		if out.Content == nil {
+			out.Content = &llm.Content{Role: llm.RoleModel}
+		}
+		for _, part := range out.Content.Parts {
+			select {
+			case stream <- part:
+			case <-ctx.Done():
+				// The caller gave up before the response was streamed.
+				out.Err = ctx.Err()
+				out.FinishReason = llm.FinishReasonError
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (r *Router) generate(ctx context.Context, chat *llm.ChatContext, input *llm.Content) (*llm.StreamContent, error) {
+	var errs []error
+	for _, b := range r.order() {
+		resp, err := b.generate(ctx, r.timeout, chat, input)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Warn().Err(err).Str("backend", b.name).Msg("llm backend failed, falling back")
+		errs = append(errs, fmt.Errorf("%s: %w", b.name, err))
+	}
+	return nil, fmt.Errorf("%w: %w", ErrNoBackend, errors.Join(errs...))
+}
+
+// Name returns the names of the backends, in order.
+func (r *Router) Name() string {
+	names := make([]string, len(r.backends))
+	for i, b := range r.backends {
+		names[i] = b.name
+	}
+	return strings.Join(names, ",")
+}
+
+// Close does nothing: the backends may be shared with other routers, and are closed
+// with their Routes.
+func (r *Router) Close() error {
+	return nil
+}
@@ -0,0 +1,222 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lemon-mint/coord"
+	"github.com/lemon-mint/coord/llm"
+	"github.com/lemon-mint/coord/pconf"
+	"github.com/lemon-mint/coord/provider"
+	"gosuda.org/website/internal/config"
+)
+
+// fakeProvider is registered as the fake provider. Its models behave as they are
+// named: rate-limit fails with llm.ErrRateLimit, hang never answers, and the others
+// answer their name.
+type fakeProvider struct{}
+
+func (fakeProvider) NewLLMClient(context.Context, ...pconf.Config) (provider.LLMClient, error) {
+	return fakeClient{}, nil
+}
+
+type fakeClient struct{}
+
+func (fakeClient) NewLLM(model string, _ *llm.Config) (llm.Model, error) {
+	if model == "missing" {
+		return nil, llm.ErrNotFound
+	}
+	return fakeModel(model), nil
+}
+
+func (fakeClient) Close() error { return nil }
+
+type fakeModel string
+
+func (m fakeModel) GenerateStream(ctx context.Context, _ *llm.ChatContext, _ *llm.Content) *llm.StreamContent {
+	stream := make(chan llm.Segment)
+	resp := &llm.StreamContent{Stream: stream}
+	go func() {
+		defer close(stream)
+		switch m {
+		case "hang":
+			// Ignores the context, like a stuck connection.
+			select {}
+		case "rate-limit":
+			resp.Err = fmt.Errorf("fake: %w", llm.ErrRateLimit)
+			resp.Content = &llm.Content{Role: llm.RoleModel}
+			resp.FinishReason = llm.FinishReasonError
+		default:
+			resp.Content = llm.TextContent(llm.RoleModel, string(m))
+			resp.FinishReason = llm.FinishReasonStop
+			stream <- llm.Text(m)
+		}
+	}()
+	return resp
+}
+
+func (m fakeModel) Close() error { return nil }
+func (m fakeModel) Name() string { return string(m) }
+
+func init() {
+	coord.RegisterLLMProvider("fake", fakeProvider{})
+}
+
+// setClock replaces the clock of the health of backends until the end of the test.
+func setClock(t *testing.T) *time.Time {
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+	return &clock
+}
+
+func fakeConfig(routes map[string][]string, models ...string) config.LLM {
+	c := config.LLM{Backends: make(map[string]config.Backend), Routes: routes}
+	for _, model := range models {
+		c.Backends[model] = config.Backend{Provider: "fake", APIKey: "key", Model: model}
+	}
+	return c
+}
+
+func generate(ctx context.Context, m llm.Model) (string, error) {
+	resp := m.GenerateStream(ctx, &llm.ChatContext{}, llm.TextContent(llm.RoleUser, "hello"))
+	var sb strings.Builder
+	for s := range resp.Stream {
+		sb.WriteString(string(s.(llm.Text)))
+	}
+	if resp.Err != nil {
+		return "", resp.Err
+	}
+	if sb.String() != resp.Text() {
+		return "", fmt.Errorf("streamed %q, but the content is %q", sb.String(), resp.Text())
+	}
+	return sb.String(), nil
+}
+
+func stats(r *Routes) map[string]Stats {
+	m := make(map[string]Stats)
+	for _, b := range r.Backends() {
+		m[b.Name()] = b.Stats()
+	}
+	return m
+}
+
+func TestFallback(t *testing.T) {
+	clock := setClock(t)
+	routes, err := Open(context.Background(), fakeConfig(map[string][]string{
+		config.TaskDefault: {"rate-limit", "first"},
+		config.TaskTitle:   {"second", "rate-limit"},
+	}, "rate-limit", "first", "second"), config.TaskTitle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer routes.Close()
+
+	for i, want := range []Stats{
+		{Calls: 1, Failures: 1},
+		// Cooling down after its failure, and skipped.
+		{Calls: 1, Failures: 1},
+	} {
+		text, err := generate(context.Background(), routes.Model(config.TaskTranslation))
+		if err != nil || text != "first" {
+			t.Fatalf("call %d = %q, %v, want first", i, text, err)
+		}
+		if got := stats(routes)["rate-limit"]; got != want {
+			t.Errorf("call %d: rate-limit stats = %+v, want %+v", i, got, want)
+		}
+	}
+
+	// The backend is tried again after its cooldown, which doubles when it fails again.
+	*clock = clock.Add(cooldown)
+	generate(context.Background(), routes.Model(config.TaskDefault))
+	*clock = clock.Add(cooldown)
+	generate(context.Background(), routes.Model(config.TaskDefault))
+	if got := stats(routes)["rate-limit"]; got.Calls != 2 {
+		t.Errorf("rate-limit stats = %+v, want 2 calls", got)
+	}
+
+	text, err := generate(context.Background(), routes.Model(config.TaskTitle))
+	if err != nil || text != "second" {
+		t.Errorf("title = %q, %v, want second", text, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	setClock(t)
+	r := New(10*time.Millisecond, NewBackend("hang", fakeModel("hang")), NewBackend("ok", fakeModel("ok")))
+	text, err := generate(context.Background(), r)
+	if err != nil || text != "ok" {
+		t.Fatalf("generate = %q, %v, want ok", text, err)
+	}
+	if r.backends[0].healthy() {
+		t.Error("backend that timed out is healthy")
+	}
+}
+
+func TestNoBackend(t *testing.T) {
+	setClock(t)
+	b := NewBackend("rate-limit", fakeModel("rate-limit"))
+	r := New(0, b)
+	for range 2 {
+		_, err := generate(context.Background(), r)
+		if !errors.Is(err, ErrNoBackend) || !errors.Is(err, llm.ErrRateLimit) {
+			t.Fatalf("generate = %v, want ErrNoBackend and ErrRateLimit", err)
+		}
+	}
+	// Backends cooling down are still tried when no backend is healthy.
+	if got := b.Stats(); got.Calls != 2 {
+		t.Errorf("stats = %+v, want 2 calls", got)
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	setClock(t)
+	b := NewBackend("hang", fakeModel("hang"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := generate(ctx, New(time.Minute, b))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrNoBackend) {
+		t.Fatalf("generate = %v, want the error of the context", err)
+	}
+	if !b.healthy() || b.Stats().Calls != 0 {
+		t.Error("backend is blamed for the caller giving up")
+	}
+}
+
+func TestCanceledStream(t *testing.T) {
+	setClock(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := New(time.Minute, NewBackend("ok", fakeModel("ok"))).GenerateStream(ctx, &llm.ChatContext{}, llm.TextContent(llm.RoleUser, "hello"))
+	cancel()
+	for range resp.Stream {
+	}
+	if !errors.Is(resp.Err, context.Canceled) {
+		t.Errorf("Err = %v, want the error of the context", resp.Err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	c := fakeConfig(map[string][]string{
+		config.TaskDefault:    {"missing", "no-key", "ok"},
+		config.TaskEvaluation: {"missing", "no-key"},
+	}, "missing", "no-key", "ok")
+	c.Backends["no-key"] = config.Backend{Provider: "fake", Model: "no-key"}
+
+	routes, err := Open(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := routes.Model(config.TaskEvaluation).Name(); name != "ok" {
+		t.Errorf("evaluation route = %s, want the default route", name)
+	}
+	routes.Close()
+
+	_, err = Open(context.Background(), c, config.TaskEvaluation)
+	if !errors.Is(err, ErrEmptyRoute) {
+		t.Errorf("Open = %v, want ErrEmptyRoute", err)
+	}
+}
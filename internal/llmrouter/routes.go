@@ -0,0 +1,116 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lemon-mint/coord"
+	"github.com/lemon-mint/coord/llm"
+	"github.com/lemon-mint/coord/pconf"
+	"github.com/lemon-mint/coord/provider"
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/config"
+)
+
+var ErrEmptyRoute = errors.New("no backend of the route is available")
+
+// Routes are the routers of the tasks of an LLM configuration, sharing its backends.
+type Routes struct {
+	routers  map[string]*Router
+	backends []*Backend
+	clients  []provider.LLMClient
+}
+
+// Open creates the backends of c and a router for each task. Backends without
+// credentials, and those whose client or model cannot be created, are left out of
+// their routes; Open only fails if that leaves a route empty.
+func Open(ctx context.Context, c config.LLM, tasks ...string) (*Routes, error) {
+	r := &Routes{routers: make(map[string]*Router)}
+	backends := make(map[string]*Backend)
+	for _, task := range append([]string{config.TaskDefault}, tasks...) {
+		var route []*Backend
+		for _, name := range c.Route(task) {
+			b, ok := backends[name]
+			if !ok {
+				b = r.open(ctx, name, c.Backends[name])
+				backends[name] = b
+			}
+			if b != nil {
+				route = append(route, b)
+			}
+		}
+		if len(route) == 0 {
+			r.Close()
+			return nil, fmt.Errorf("%w: %s", ErrEmptyRoute, task)
+		}
+		r.routers[task] = New(time.Duration(c.Timeout)*time.Second, route...)
+	}
+	return r, nil
+}
+
+// open creates the backend, or returns nil if it is not available.
+func (r *Routes) open(ctx context.Context, name string, c config.Backend) *Backend {
+	if !c.Configured() {
+		log.Debug().Str("backend", name).Msg("llm backend has no credentials, skipped")
+		return nil
+	}
+
+	log.Debug().Str("backend", name).Str("provider", c.Provider).Str("model", c.Model).Msg("initializing llm backend")
+	client, err := coord.NewLLMClient(ctx, c.Provider,
+		pconf.WithAPIKey(c.APIKey),
+		pconf.WithBaseURL(c.BaseURL),
+		pconf.WithProjectID(c.ProjectID),
+		pconf.WithLocation(c.Location),
+	)
+	if err != nil {
+		log.Error().Err(err).Str("backend", name).Msg("failed to create llm client")
+		return nil
+	}
+	thinking := llm.ThinkingLevelMinimal
+	model, err := client.NewLLM(c.Model, &llm.Config{
+		Temperature:           &c.Temperature,
+		MaxOutputTokens:       &c.MaxOutputTokens,
+		SafetyFilterThreshold: llm.BlockOff,
+		ThinkingConfig: &llm.ThinkingConfig{
+			ThinkingLevel: &thinking,
+		},
+	})
+	if err != nil {
+		client.Close()
+		log.Error().Err(err).Str("backend", name).Msg("failed to create llm model")
+		return nil
+	}
+
+	r.clients = append(r.clients, client)
+	b := NewBackend(name, model)
+	r.backends = append(r.backends, b)
+	return b
+}
+
+// Model returns the router of the task, or of the default route if the task was not
+// opened.
+func (r *Routes) Model(task string) llm.Model {
+	if m, ok := r.routers[task]; ok {
+		return m
+	}
+	return r.routers[config.TaskDefault]
+}
+
+// Backends returns the backends in the order they were opened.
+func (r *Routes) Backends() []*Backend {
+	return r.backends
+}
+
+// Close closes the models and the clients of the backends.
+func (r *Routes) Close() error {
+	var errs []error
+	for _, b := range r.backends {
+		errs = append(errs, b.model.Close())
+	}
+	for _, c := range r.clients {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
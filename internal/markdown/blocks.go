@@ -0,0 +1,282 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindAdmonition is the kind of Admonition nodes.
+var KindAdmonition = ast.NewNodeKind("Admonition")
+
+// Admonition is a callout block, written as a blockquote starting with a
+// GitHub alert marker such as "> [!NOTE]".
+type Admonition struct {
+	ast.BaseBlock
+	// AdmonitionKind is the lower case kind of the callout, e.g. "note".
+	AdmonitionKind string
+}
+
+func (n *Admonition) Kind() ast.NodeKind {
+	return KindAdmonition
+}
+
+func (n *Admonition) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"AdmonitionKind": n.AdmonitionKind}, nil)
+}
+
+// KindCodeTabs is the kind of CodeTabs nodes.
+var KindCodeTabs = ast.NewNodeKind("CodeTabs")
+
+// CodeTabs groups consecutive fenced code blocks labeled with a tab attribute,
+// e.g. "```go tab=Server", into a single tabbed sample. Its children are CodeTab nodes.
+type CodeTabs struct {
+	ast.BaseBlock
+	// Index numbers the groups of a document, to derive unique element IDs.
+	Index int
+}
+
+func (n *CodeTabs) Kind() ast.NodeKind {
+	return KindCodeTabs
+}
+
+func (n *CodeTabs) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Index": strconv.Itoa(n.Index)}, nil)
+}
+
+// KindCodeTab is the kind of CodeTab nodes.
+var KindCodeTab = ast.NewNodeKind("CodeTab")
+
+// CodeTab is a single tab of CodeTabs, holding one fenced code block.
+type CodeTab struct {
+	ast.BaseBlock
+	Label string
+}
+
+func (n *CodeTab) Kind() ast.NodeKind {
+	return KindCodeTab
+}
+
+func (n *CodeTab) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Label": n.Label}, nil)
+}
+
+// admonitionKinds are the supported admonition kinds. Their titles are messages of
+// the catalog, filled in by AdmonitionTitles for the language of the page.
+var admonitionKinds = map[string]bool{
+	"note":      true,
+	"tip":       true,
+	"important": true,
+	"warning":   true,
+	"caution":   true,
+}
+
+var (
+	admonitionMarkerRegex = regexp.MustCompile(`^\[!([A-Za-z]+)\]\s*$`)
+	tabLabelRegex         = regexp.MustCompile(`(?:^|\s)tab=(?:"([^"]*)"|(\S+))`)
+)
+
+// TabLabel returns the tab label of a fenced code block info string, or an empty string.
+func TabLabel(info string) string {
+	m := tabLabelRegex.FindStringSubmatch(info)
+	if m == nil {
+		return ""
+	}
+	return m[1] + m[2]
+}
+
+// blocksTransformer turns alert blockquotes into admonitions and groups tabbed code blocks.
+type blocksTransformer struct{}
+
+func (t *blocksTransformer) Transform(node *ast.Document, reader text.Reader, pctx parser.Context) {
+	source := reader.Source()
+
+	var quotes []*ast.Blockquote
+	var containers []ast.Node
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Blockquote:
+			quotes = append(quotes, n)
+		case *ast.FencedCodeBlock:
+			if len(containers) == 0 || containers[len(containers)-1] != n.Parent() {
+				containers = append(containers, n.Parent())
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, quote := range quotes {
+		transformAdmonition(quote, source)
+	}
+
+	index := 0
+	for _, container := range containers {
+		index = groupCodeTabs(container, source, index)
+	}
+}
+
+// transformAdmonition replaces quote with an Admonition if its first line is an alert marker.
+func transformAdmonition(quote *ast.Blockquote, source []byte) {
+	para, ok := quote.FirstChild().(*ast.Paragraph)
+	if !ok || para.Lines().Len() == 0 {
+		return
+	}
+	first := para.Lines().At(0)
+	m := admonitionMarkerRegex.FindSubmatch(first.Value(source))
+	if m == nil {
+		return
+	}
+	kind := strings.ToLower(string(m[1]))
+	if !admonitionKinds[kind] {
+		return
+	}
+
+	// Remove the inline nodes of the marker line.
+	for c := para.FirstChild(); c != nil; {
+		t, ok := c.(*ast.Text)
+		if !ok || t.Segment.Start >= first.Stop {
+			break
+		}
+		c = c.NextSibling()
+		para.RemoveChild(para, t)
+	}
+	if !para.HasChildren() {
+		quote.RemoveChild(quote, para)
+	}
+
+	admonition := &Admonition{AdmonitionKind: kind}
+	for c := quote.FirstChild(); c != nil; {
+		next := c.NextSibling()
+		admonition.AppendChild(admonition, c)
+		c = next
+	}
+	quote.Parent().ReplaceChild(quote.Parent(), quote, admonition)
+}
+
+// groupCodeTabs wraps runs of consecutive tabbed code blocks among the children of container.
+func groupCodeTabs(container ast.Node, source []byte, index int) int {
+	var group *CodeTabs
+	for c := container.FirstChild(); c != nil; {
+		next := c.NextSibling()
+
+		block, ok := c.(*ast.FencedCodeBlock)
+		label := ""
+		if ok && block.Info != nil {
+			label = TabLabel(string(block.Info.Segment.Value(source)))
+		}
+		if label == "" {
+			group = nil
+			c = next
+			continue
+		}
+
+		if group == nil {
+			group = &CodeTabs{Index: index}
+			index++
+			container.InsertBefore(container, block, group)
+		}
+		tab := &CodeTab{Label: label}
+		container.RemoveChild(container, block)
+		tab.AppendChild(tab, block)
+		group.AppendChild(group, tab)
+
+		c = next
+	}
+	return index
+}
+
+// blocksRenderer renders admonitions and tabbed code blocks.
+type blocksRenderer struct{}
+
+func (r *blocksRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindAdmonition, r.renderAdmonition)
+	reg.Register(KindCodeTabs, r.renderCodeTabs)
+	reg.Register(KindCodeTab, r.renderCodeTab)
+}
+
+func (r *blocksRenderer) renderAdmonition(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*Admonition)
+	if entering {
+		w.WriteString(`<div class="admonition admonition-` + n.AdmonitionKind + `" data-admonition="` + n.AdmonitionKind + `" role="note">` + "\n")
+		w.WriteString(`<p class="admonition-title"></p>` + "\n")
+	} else {
+		w.WriteString("</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// admonitionTitleRegex matches the opening tag of a rendered admonition and its empty title.
+var admonitionTitleRegex = regexp.MustCompile(`data-admonition="([a-z]+)" role="note">\n<p class="admonition-title"></p>`)
+
+// AdmonitionTitles fills the titles of the admonitions of html with title(kind), so
+// that rendered documents, which are stored, stay independent of the message catalog.
+func AdmonitionTitles(html string, title func(kind string) string) string {
+	return admonitionTitleRegex.ReplaceAllStringFunc(html, func(tag string) string {
+		kind := admonitionTitleRegex.FindStringSubmatch(tag)[1]
+		return strings.TrimSuffix(tag, "</p>") + string(util.EscapeHTML([]byte(title(kind)))) + "</p>"
+	})
+}
+
+func codeTabID(group, tab int) string {
+	return "code-tabs-" + strconv.Itoa(group) + "-" + strconv.Itoa(tab)
+}
+
+func (r *blocksRenderer) renderCodeTabs(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*CodeTabs)
+	if !entering {
+		w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+
+	w.WriteString(`<div class="code-tabs" data-code-tabs>` + "\n")
+	w.WriteString(`<div class="code-tabs-list" role="tablist">`)
+	i := 0
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		id := codeTabID(n.Index, i)
+		selected := strconv.FormatBool(i == 0)
+		w.WriteString(`<button type="button" role="tab" id="` + id + `-tab" aria-controls="` + id + `" aria-selected="` + selected + `">`)
+		w.Write(util.EscapeHTML([]byte(c.(*CodeTab).Label)))
+		w.WriteString("</button>")
+		i++
+	}
+	w.WriteString("</div>\n")
+	return ast.WalkContinue, nil
+}
+
+func (r *blocksRenderer) renderCodeTab(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+
+	group := node.Parent().(*CodeTabs)
+	i := 0
+	for c := group.FirstChild(); c != node; c = c.NextSibling() {
+		i++
+	}
+	id := codeTabID(group.Index, i)
+	w.WriteString(`<div class="code-tab" role="tabpanel" id="` + id + `" aria-labelledby="` + id + `-tab">` + "\n")
+	return ast.WalkContinue, nil
+}
+
+// blocks is a goldmark extension for admonitions and tabbed code blocks.
+type blocks struct{}
+
+func (e *blocks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&blocksTransformer{}, 100),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&blocksRenderer{}, 500),
+	))
+}
@@ -0,0 +1,55 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAdmonition(t *testing.T) {
+	doc, err := ParseMarkdown("> [!WARNING]\n> Do not *panic*.\n\n> [!UNKNOWN]\n> Plain quote.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`<div class="admonition admonition-warning"`,
+		`<p class="admonition-title"></p>`,
+		"<p>Do not <em>panic</em>.</p>",
+		"<blockquote>\n<p>[!UNKNOWN]",
+	} {
+		if !strings.Contains(doc.HTML, s) {
+			t.Errorf("expected %q in:\n%s", s, doc.HTML)
+		}
+	}
+
+	html := AdmonitionTitles(doc.HTML, func(kind string) string { return "주의 <" + kind + ">" })
+	if !strings.Contains(html, `<p class="admonition-title">주의 &lt;warning&gt;</p>`) {
+		t.Errorf("expected the title of the page language in:\n%s", html)
+	}
+	if strings.Contains(doc.HTML, "[!WARNING]") {
+		t.Errorf("expected the admonition marker to be removed:\n%s", doc.HTML)
+	}
+}
+
+func TestCodeTabs(t *testing.T) {
+	doc, err := ParseMarkdown("```go tab=Server\npackage server\n```\n\n```go tab=\"Go Client\"\npackage client\n```\n\n```go\npackage other\n```\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(doc.HTML, "data-code-tabs"); n != 1 {
+		t.Fatalf("expected 1 tab group, got %d:\n%s", n, doc.HTML)
+	}
+	for _, s := range []string{
+		`aria-controls="code-tabs-0-0" aria-selected="true">Server</button>`,
+		`aria-controls="code-tabs-0-1" aria-selected="false">Go Client</button>`,
+		`role="tabpanel" id="code-tabs-0-1"`,
+	} {
+		if !strings.Contains(doc.HTML, s) {
+			t.Errorf("expected %q in:\n%s", s, doc.HTML)
+		}
+	}
+	if i, j := strings.LastIndex(doc.HTML, "</div>"), strings.Index(doc.HTML, "other"); i > j {
+		t.Errorf("expected the untabbed code block after the tab group:\n%s", doc.HTML)
+	}
+}
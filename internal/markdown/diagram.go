@@ -0,0 +1,152 @@
+package markdown
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"github.com/zeebo/blake3"
+	"gosuda.org/website/internal/diagram"
+)
+
+// diagramCacheDir holds rendered diagrams across builds, keyed by the hash of their source.
+const diagramCacheDir = ".cache/diagrams"
+
+var diagramCache sync.Map
+
+// KindDiagram is the kind of Diagram nodes.
+var KindDiagram = ast.NewNodeKind("Diagram")
+
+// Diagram is a fenced code block written in a diagram language, e.g. "```mermaid".
+type Diagram struct {
+	ast.BaseBlock
+	Language string
+	Source   string
+	// Repeat counts the previous diagrams of the document with the same source, whose
+	// SVG elements must not share ids with this one.
+	Repeat int
+}
+
+func (n *Diagram) Kind() ast.NodeKind {
+	return KindDiagram
+}
+
+func (n *Diagram) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Language": n.Language}, nil)
+}
+
+// diagramTransformer replaces fenced code blocks of diagram languages with Diagram nodes.
+type diagramTransformer struct{}
+
+func (t *diagramTransformer) Transform(node *ast.Document, reader text.Reader, pctx parser.Context) {
+	source := reader.Source()
+
+	var blocks []*ast.FencedCodeBlock
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if block, ok := n.(*ast.FencedCodeBlock); ok && entering && diagram.IsLanguage(string(block.Language(source))) {
+			blocks = append(blocks, block)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	seen := make(map[string]int)
+	for _, block := range blocks {
+		var b strings.Builder
+		lines := block.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			b.Write(line.Value(source))
+		}
+		d := &Diagram{Language: strings.ToLower(string(block.Language(source))), Source: b.String()}
+		d.Repeat = seen[d.Language+"\x00"+d.Source]
+		seen[d.Language+"\x00"+d.Source]++
+		block.Parent().ReplaceChild(block.Parent(), block, d)
+	}
+}
+
+// renderDiagram renders a diagram to SVG, reusing a previous rendering of the same source.
+// The ids of its elements are derived from the hash of the source, and from repeat.
+func renderDiagram(lang, source string, repeat int) (string, error) {
+	sum := blake3.Sum256([]byte(strconv.Itoa(diagram.Version) + "\x00" + lang + "\x00" + source))
+	key := hex.EncodeToString(sum[:16])
+	id := "diagram-" + key[:12]
+	if repeat > 0 {
+		key += "-" + strconv.Itoa(repeat)
+		id += "-" + strconv.Itoa(repeat)
+	}
+
+	if svg, ok := diagramCache.Load(key); ok {
+		return svg.(string), nil
+	}
+
+	path := filepath.Join(diagramCacheDir, key+".svg")
+	if data, err := os.ReadFile(path); err == nil {
+		diagramCache.Store(key, string(data))
+		return string(data), nil
+	}
+
+	svg, err := diagram.Render(lang, source, id)
+	if err != nil {
+		return "", err
+	}
+	diagramCache.Store(key, svg)
+
+	err = os.MkdirAll(diagramCacheDir, 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(svg), 0644)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("failed to cache rendered diagram")
+	}
+	return svg, nil
+}
+
+// diagramRenderer renders diagrams as inline SVG, or as their source if they cannot be rendered.
+type diagramRenderer struct{}
+
+func (r *diagramRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindDiagram, r.renderDiagram)
+}
+
+func (r *diagramRenderer) renderDiagram(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Diagram)
+
+	svg, err := renderDiagram(n.Language, n.Source, n.Repeat)
+	if err != nil {
+		log.Warn().Err(err).Str("language", n.Language).Msg("failed to render diagram, showing its source")
+		w.WriteString(`<pre class="diagram-source"><code class="language-` + n.Language + `">`)
+		w.Write(util.EscapeHTML([]byte(n.Source)))
+		w.WriteString("</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+
+	w.WriteString(`<div class="diagram-container" data-diagram="` + n.Language + `">`)
+	w.WriteString(svg)
+	w.WriteString("</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// diagrams is a goldmark extension that renders diagram code blocks to SVG at build time.
+type diagrams struct{}
+
+func (e *diagrams) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&diagramTransformer{}, 100),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&diagramRenderer{}, 500),
+	))
+}
@@ -0,0 +1,41 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiagram(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	const flowchart = "```mermaid\ngraph LR\n  A[Write] --> B[Build]\n```\n\n"
+	doc, err := ParseMarkdown(flowchart + "```mermaid\nsequenceDiagram\n  A->>B: Hi\n```\n\n" + flowchart)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(doc.HTML, `<div class="diagram-container" data-diagram="mermaid"><svg `) {
+		t.Errorf("expected an inline SVG diagram:\n%s", doc.HTML)
+	}
+	if !strings.Contains(doc.HTML, `<pre class="diagram-source"><code class="language-mermaid">sequenceDiagram`) {
+		t.Errorf("expected the unsupported diagram to be shown as source:\n%s", doc.HTML)
+	}
+
+	// The repeated diagram has its own arrowhead marker.
+	markers := regexp.MustCompile(`<marker id="([^"]*)"`).FindAllStringSubmatch(doc.HTML, -1)
+	if len(markers) != 2 || markers[0][1] == markers[1][1] {
+		t.Errorf("expected 2 distinct arrowhead markers, got %q", markers)
+	}
+
+	cached, err := filepath.Glob(filepath.Join(diagramCacheDir, "*.svg"))
+	if err != nil || len(cached) != 2 {
+		t.Fatalf("expected 2 cached diagrams, got %v (%v)", cached, err)
+	}
+	data, err := os.ReadFile(cached[0])
+	if err != nil || !strings.Contains(doc.HTML, string(data)) {
+		t.Errorf("expected the cached diagram to match the rendered one")
+	}
+}
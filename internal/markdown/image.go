@@ -0,0 +1,152 @@
+package markdown
+
+import (
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"gosuda.org/website/internal/imagemeta"
+	"gosuda.org/website/internal/responsive"
+)
+
+var (
+	// imageCache provides the dimensions of images; without it, images are rendered without dimensions.
+	imageCache *imagemeta.Cache
+	// imageVariants provides the resized variants of local images.
+	imageVariants *responsive.Processor
+)
+
+// SetImageCache sets the cache that provides the dimensions of images.
+func SetImageCache(c *imagemeta.Cache) {
+	imageCache = c
+}
+
+// SetImageVariants sets the processor that provides the variants of local images.
+// The variants must be generated before rendering.
+func SetImageVariants(p *responsive.Processor) {
+	imageVariants = p
+}
+
+// KindPicture is the kind of Picture nodes.
+var KindPicture = ast.NewNodeKind("Picture")
+
+// Picture wraps an image that is also available in other formats.
+type Picture struct {
+	ast.BaseInline
+	Sources []responsive.Source
+}
+
+func (n *Picture) Kind() ast.NodeKind {
+	return KindPicture
+}
+
+func (n *Picture) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// imageTransformer adds the dimensions, variants and loading hints of images.
+type imageTransformer struct{}
+
+var defaultImageTransformer = &imageTransformer{}
+
+func (t *imageTransformer) Transform(node *ast.Document, reader text.Reader, pctx parser.Context) {
+	var images []*ast.Image
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if img, ok := n.(*ast.Image); ok && entering {
+			images = append(images, img)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, img := range images {
+		src := string(img.Destination)
+
+		// External images are only read from the cache, which is filled before rendering.
+		width, height, ok := imageCache.Size(src)
+		if ok {
+			img.SetAttributeString("width", []byte(strconv.Itoa(width)))
+			img.SetAttributeString("height", []byte(strconv.Itoa(height)))
+		} else {
+			log.Debug().Str("src", src).Msg("image dimensions are unknown")
+		}
+		img.SetAttributeString("loading", []byte("lazy"))
+		img.SetAttributeString("decoding", []byte("async"))
+
+		set := imageVariants.Variants(src)
+		if set == nil {
+			continue
+		}
+		img.SetAttributeString("srcset", []byte(set.Fallback.Srcset()))
+		img.SetAttributeString("sizes", []byte(responsive.Sizes))
+		if len(set.Alternates) > 0 {
+			picture := &Picture{Sources: set.Alternates}
+			img.Parent().ReplaceChild(img.Parent(), img, picture)
+			picture.AppendChild(picture, img)
+		}
+	}
+}
+
+// sourceParser parses documents without the transformers of gMark, which need the
+// images to be prepared.
+var sourceParser = goldmark.New(
+	goldmark.WithExtensions(
+		meta.Meta,
+		extension.GFM,
+		extension.Footnote,
+		extension.DefinitionList,
+	),
+).Parser()
+
+// ImageSources returns the sources of the images of a markdown document.
+func ImageSources(source string) []string {
+	doc := sourceParser.Parse(text.NewReader([]byte(source)))
+
+	var sources []string
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if img, ok := n.(*ast.Image); ok && entering {
+			sources = append(sources, string(img.Destination))
+		}
+		return ast.WalkContinue, nil
+	})
+	return sources
+}
+
+// pictureRenderer renders pictures with a source element for each alternate format.
+type pictureRenderer struct{}
+
+func (r *pictureRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindPicture, r.renderPicture)
+}
+
+func (r *pictureRenderer) renderPicture(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		w.WriteString("</picture>")
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Picture)
+
+	w.WriteString("<picture>")
+	for _, s := range n.Sources {
+		w.WriteString(`<source type="` + s.Format.Type + `" srcset="`)
+		w.Write(util.EscapeHTML([]byte(s.Srcset())))
+		w.WriteString(`" sizes="` + responsive.Sizes + `">`)
+	}
+	return ast.WalkContinue, nil
+}
+
+// pictures is a goldmark extension that renders images with alternate formats as
+// picture elements.
+type pictures struct{}
+
+func (e *pictures) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&pictureRenderer{}, 500),
+	))
+}
@@ -1,32 +1,16 @@
 package markdown
 
 import (
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
-
 	"bytes"
 	"errors"
-	"fmt"
-	"image"
-	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
-
-	_ "golang.org/x/image/webp"
 
 	chtml "github.com/alecthomas/chroma/v2/formatters/html"
-	"github.com/rs/zerolog/log"
 	treeblood "github.com/wyatt915/goldmark-treeblood"
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	meta "github.com/yuin/goldmark-meta"
-	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
 	"gopkg.in/yaml.v3"
 	"gosuda.org/website/internal/types"
@@ -35,80 +19,6 @@ import (
 
 var ErrInvalidMetadata = errors.New("invalid metadata")
 
-type imageDimensionTransformer struct{}
-
-var defaultImageDimensionTransformer = &imageDimensionTransformer{}
-
-func (t *imageDimensionTransformer) Transform(node *ast.Document, reader text.Reader, pctx parser.Context) {
-	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering {
-			return ast.WalkContinue, nil
-		}
-
-		if n.Kind() == ast.KindImage {
-			img := n.(*ast.Image)
-			src := string(img.Destination)
-
-			log.Debug().Str("src", src).Msg("Processing image")
-
-			// Check if the image source is a local path
-			if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
-				// Construct the full path to the image file.
-				// Assuming image paths in markdown are relative to the 'public' directory.
-				// This might need adjustment based on actual image location practices.
-				imgPath := filepath.Join("public", src)
-
-				file, err := os.Open(imgPath)
-				if err != nil {
-					log.Error().Err(err).Str("path", imgPath).Msg("Error opening local image file")
-					return ast.WalkContinue, nil
-				}
-				defer file.Close()
-
-				imgConfig, _, err := image.DecodeConfig(file)
-				if err != nil {
-					log.Error().Err(err).Str("path", imgPath).Msg("Error decoding local image config")
-					return ast.WalkContinue, nil
-				}
-
-				// Add width and height attributes
-				img.SetAttributeString("width", []byte(fmt.Sprintf("%d", imgConfig.Width)))
-				img.SetAttributeString("height", []byte(fmt.Sprintf("%d", imgConfig.Height)))
-				log.Debug().Str("src", src).Int("width", imgConfig.Width).Int("height", imgConfig.Height).Msg("Added dimensions to local image")
-			} else {
-				// Handle external images
-				client := http.Client{
-					Timeout: 5 * time.Second, // Set a timeout for fetching the image header
-				}
-
-				resp, err := client.Get(src)
-				if err != nil {
-					log.Error().Err(err).Str("src", src).Msg("Error fetching external image")
-					return ast.WalkContinue, nil
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode != http.StatusOK {
-					log.Error().Str("src", src).Int("status", resp.StatusCode).Msg("External image returned non-OK status")
-					return ast.WalkContinue, nil
-				}
-
-				imgConfig, _, err := image.DecodeConfig(resp.Body)
-				if err != nil {
-					log.Error().Err(err).Str("src", src).Msg("Error decoding external image config")
-					return ast.WalkContinue, nil
-				}
-
-				// Add width and height attributes
-				img.SetAttributeString("width", []byte(fmt.Sprintf("%d", imgConfig.Width)))
-				img.SetAttributeString("height", []byte(fmt.Sprintf("%d", imgConfig.Height)))
-				log.Debug().Str("src", src).Int("width", imgConfig.Width).Int("height", imgConfig.Height).Msg("Added dimensions to external image")
-			}
-		}
-		return ast.WalkContinue, nil
-	})
-}
-
 var gMark = goldmark.New(
 	goldmark.WithExtensions(
 		meta.New(meta.WithStoresInDocument()),
@@ -126,10 +36,17 @@ var gMark = goldmark.New(
 		treeblood.MathML(),
 		extension.GFM,
 		extension.CJK,
+		extension.Footnote,
+		extension.DefinitionList,
+		&blocks{},
+		&diagrams{},
+		&pictures{},
 	),
 	goldmark.WithParserOptions(
 		parser.WithASTTransformers(
-			util.Prioritized(defaultImageDimensionTransformer, 0),
+			util.Prioritized(defaultImageTransformer, 0),
+			util.Prioritized(defaultTOCTransformer, 0),
+			util.Prioritized(defaultStatsTransformer, 0),
 		),
 	),
 )
@@ -153,13 +70,33 @@ func parseMetadata(doc *types.Document, metadata map[string]interface{}) error {
 
 	return nil
 }
-func ParseMarkdown(text string) (*types.Document, error) {
+
+// RendererVersion is incremented whenever rendering changes, so that stored
+// translations are rendered again from their markdown.
+const RendererVersion = 7
+
+// Option configures ParseMarkdown.
+type Option func(ctx parser.Context)
+
+// WithHeadingIDs assigns ids to the headings of the document in order, so that a
+// translation keeps the heading anchors of its main language version.
+func WithHeadingIDs(ids []string) Option {
+	return func(ctx parser.Context) {
+		ctx.Set(headingIDsKey, ids)
+	}
+}
+
+func ParseMarkdown(text string, opts ...Option) (*types.Document, error) {
 	doc := &types.Document{
 		Type:     types.DocumentTypeMarkdown,
 		Markdown: text,
+		Renderer: RendererVersion,
 	}
 
 	context := parser.NewContext()
+	for _, opt := range opts {
+		opt(context)
+	}
 	var buf bytes.Buffer
 
 	err := gMark.Convert([]byte(text), &buf, parser.WithContext(context))
@@ -174,6 +111,8 @@ func ParseMarkdown(text string) (*types.Document, error) {
 	}
 
 	doc.HTML = buf.String()
+	doc.TOC, _ = context.Get(tocKey).([]*types.TOCEntry)
+	doc.Stats, _ = context.Get(statsKey).(types.TextStats)
 
 	return doc, nil
 }
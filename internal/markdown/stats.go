@@ -0,0 +1,98 @@
+package markdown
+
+import (
+	"math"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"gosuda.org/website/internal/types"
+)
+
+// Reading speeds used for the estimated reading time. Scripts written without
+// spaces between words are read and counted character by character.
+const (
+	wordsPerMinute     = 230
+	ideogramsPerMinute = 350
+	minimumReadingTime = 1
+)
+
+var statsKey = parser.NewContextKey()
+
+// statsTransformer counts the prose of a document. Code blocks are not counted.
+type statsTransformer struct{}
+
+var defaultStatsTransformer = &statsTransformer{}
+
+func (t *statsTransformer) Transform(node *ast.Document, reader text.Reader, pctx parser.Context) {
+	source := reader.Source()
+
+	var c counter
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if n.Type() == ast.TypeBlock {
+			c.endWord()
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			c.add(string(n.Value(source)))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				c.endWord()
+			}
+		case *ast.String:
+			c.add(string(n.Value))
+		}
+		return ast.WalkContinue, nil
+	})
+
+	pctx.Set(statsKey, c.stats())
+}
+
+// counter counts whitespace-delimited words and, separately, ideographs and kana.
+type counter struct {
+	words      int
+	ideograms  int
+	characters int
+	inWord     bool
+}
+
+func isIdeogram(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func (c *counter) add(s string) {
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			c.endWord()
+			continue
+		case isIdeogram(r):
+			c.endWord()
+			c.ideograms++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !c.inWord {
+				c.words++
+				c.inWord = true
+			}
+		}
+		c.characters++
+	}
+}
+
+func (c *counter) endWord() {
+	c.inWord = false
+}
+
+func (c *counter) stats() types.TextStats {
+	minutes := float64(c.words)/wordsPerMinute + float64(c.ideograms)/ideogramsPerMinute
+	return types.TextStats{
+		Words:          c.words + c.ideograms,
+		Characters:     c.characters,
+		ReadingMinutes: max(minimumReadingTime, int(math.Ceil(minutes))),
+	}
+}
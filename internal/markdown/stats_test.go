@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	testCases := []struct {
+		name       string
+		markdown   string
+		words      int
+		characters int
+	}{
+		{"English", "Hello, *gopher* world!\n\n```go\nfmt.Println(\"not counted\")\n```\n", 3, 18},
+		{"Korean", "고 언어는 **간결합니다**.", 3, 10},
+		{"Japanese", "Goは簡潔な言語です。", 9, 11},
+		{"Chinese", "Go 是一种简洁的语言", 9, 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ParseMarkdown(tc.markdown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Stats.Words != tc.words {
+				t.Errorf("expected %d words, got %d", tc.words, doc.Stats.Words)
+			}
+			if doc.Stats.Characters != tc.characters {
+				t.Errorf("expected %d characters, got %d", tc.characters, doc.Stats.Characters)
+			}
+			if doc.Stats.ReadingMinutes != 1 {
+				t.Errorf("expected 1 minute, got %d", doc.Stats.ReadingMinutes)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	english, _ := ParseMarkdown(strings.Repeat("word ", 1000))
+	if english.Stats.ReadingMinutes != 5 {
+		t.Errorf("expected 5 minutes for 1000 words, got %d", english.Stats.ReadingMinutes)
+	}
+
+	japanese, _ := ParseMarkdown(strings.Repeat("語", 1000))
+	if japanese.Stats.ReadingMinutes != 3 {
+		t.Errorf("expected 3 minutes for 1000 characters, got %d", japanese.Stats.ReadingMinutes)
+	}
+}
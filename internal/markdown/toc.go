@@ -0,0 +1,106 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"gosuda.org/website/internal/types"
+)
+
+var (
+	headingIDsKey = parser.NewContextKey()
+	tocKey        = parser.NewContextKey()
+)
+
+// tocTransformer collects the headings of a document into a table of contents and
+// sets their IDs, which the renderer writes as id attributes. Headings in raw HTML or
+// in diagrams are not markdown headings, so they neither get nor shift an ID.
+type tocTransformer struct{}
+
+var defaultTOCTransformer = &tocTransformer{}
+
+func (t *tocTransformer) Transform(node *ast.Document, reader text.Reader, pctx parser.Context) {
+	ids, _ := pctx.Get(headingIDsKey).([]string)
+	source := reader.Source()
+
+	var toc []*types.TOCEntry
+	used := make(map[string]bool)
+
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Kind() != ast.KindHeading {
+			return ast.WalkContinue, nil
+		}
+		heading := n.(*ast.Heading)
+		title := strings.TrimSpace(nodeText(heading, source))
+
+		var id string
+		if len(toc) < len(ids) {
+			id = ids[len(toc)]
+		} else {
+			id = headingSlug(title)
+			if id == "" {
+				id = "section-" + strconv.Itoa(len(toc)+1)
+			}
+		}
+		for base, i := id, 1; used[id]; i++ {
+			id = base + "-" + strconv.Itoa(i)
+		}
+		used[id] = true
+		heading.SetAttributeString("id", []byte(id))
+
+		toc = append(toc, &types.TOCEntry{ID: id, Level: heading.Level, Title: title})
+		return ast.WalkSkipChildren, nil
+	})
+
+	pctx.Set(tocKey, toc)
+}
+
+// nodeText returns the plain text content of n.
+func nodeText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Value(source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		default:
+			b.WriteString(nodeText(c, source))
+		}
+	}
+	return b.String()
+}
+
+// headingSlug returns the anchor of a heading: its letters and digits in lower case, joined by hyphens.
+func headingSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// HeadingIDs returns the heading IDs of toc, in document order.
+func HeadingIDs(toc []*types.TOCEntry) []string {
+	ids := make([]string, len(toc))
+	for i, entry := range toc {
+		ids[i] = entry.ID
+	}
+	return ids
+}
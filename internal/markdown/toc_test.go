@@ -0,0 +1,55 @@
+package markdown
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const tocSource = `# Title
+
+<h2>Raw HTML</h2>
+
+## Getting Started
+
+### Install ` + "`go`" + `
+
+## Getting Started
+
+## 왜 Go인가?
+`
+
+func TestTOC(t *testing.T) {
+	doc, err := ParseMarkdown(tocSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"title", "getting-started", "install-go", "getting-started-1", "왜-go인가"}
+	if ids := HeadingIDs(doc.TOC); !slices.Equal(ids, expected) {
+		t.Errorf("expected heading IDs %v, got %v", expected, ids)
+	}
+	if !strings.Contains(doc.HTML, `<h2 id="getting-started">Getting Started</h2>`) {
+		t.Errorf("heading without its ID in %q", doc.HTML)
+	}
+	if doc.TOC[2].Level != 3 || doc.TOC[2].Title != "Install go" {
+		t.Errorf("unexpected entry %+v", doc.TOC[2])
+	}
+}
+
+func TestTOCWithHeadingIDs(t *testing.T) {
+	doc, err := ParseMarkdown("# 제목\n\n## 시작하기\n\n## 추가 섹션\n", WithHeadingIDs([]string{"title", "getting-started"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"title", "getting-started", "추가-섹션"}
+	if ids := HeadingIDs(doc.TOC); !slices.Equal(ids, expected) {
+		t.Errorf("expected heading IDs %v, got %v", expected, ids)
+	}
+
+	expectedHTML := "<h1 id=\"title\">제목</h1>\n<h2 id=\"getting-started\">시작하기</h2>\n<h2 id=\"추가-섹션\">추가 섹션</h2>\n"
+	if doc.HTML != expectedHTML {
+		t.Errorf("expected HTML %q, got %q", expectedHTML, doc.HTML)
+	}
+}
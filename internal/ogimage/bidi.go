@@ -0,0 +1,252 @@
+package ogimage
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/bidi"
+	"gosuda.org/website/internal/types"
+)
+
+// Texts are drawn rune by rune from left to right. Right-to-left texts are therefore
+// shaped and reordered into the runes as they appear on screen before they are drawn,
+// after they are wrapped in logical order.
+
+// Joining types of Arabic letters: non-joining, right-joining (to the previous
+// letter only), dual-joining and join-causing. Letters of type noForms have no
+// presentation forms and do not join.
+const (
+	joinNone  = 'U'
+	joinRight = 'R'
+	joinDual  = 'D'
+	joinCause = 'C'
+	noForms   = '-'
+)
+
+// arabicJoining lists the joining types of the letters from hamza (U+0621) to yeh
+// (U+064A), whose presentation forms follow each other from U+FE80: the isolated
+// form of non-joining letters, the isolated and final forms of right-joining letters,
+// and the isolated, final, initial and medial forms of dual-joining letters.
+const arabicJoining = "URRRRDRDRDDDDDRRRRDDDDDDDD-----CDDDDDDDRRD"
+
+// persianForms are the isolated presentation forms of the letters Persian and Urdu
+// add to Arabic, which are dual-joining except for jeh.
+var persianForms = map[rune]rune{
+	'\u067E': '\uFB56', // peh
+	'\u0686': '\uFB7A', // tcheh
+	'\u0698': '\uFB8A', // jeh
+	'\u06A9': '\uFB8E', // keheh
+	'\u06AF': '\uFB92', // gaf
+	'\u06CC': '\uFBFC', // farsi yeh
+}
+
+// lamAlef are the isolated forms of the ligatures of lam with the alefs, keyed by alef.
+var lamAlef = map[rune]rune{
+	'\u0622': '\uFEF5',
+	'\u0623': '\uFEF7',
+	'\u0625': '\uFEF9',
+	'\u0627': '\uFEFB',
+}
+
+// arabicForm returns the joining type of r and its isolated presentation form, or
+// 0 if r has none.
+func arabicForm(r rune) (byte, rune) {
+	if form, ok := persianForms[r]; ok {
+		if r == '\u0698' {
+			return joinRight, form
+		}
+		return joinDual, form
+	}
+	if r < '\u0621' || r > '\u064A' {
+		return noForms, 0
+	}
+	form := rune(0xFE80)
+	for _, t := range []byte(arabicJoining[:r-'\u0621']) {
+		switch t {
+		case joinNone:
+			form++
+		case joinRight:
+			form += 2
+		case joinDual:
+			form += 4
+		}
+	}
+	t := arabicJoining[r-'\u0621']
+	if t == noForms || t == joinCause {
+		return t, 0
+	}
+	return t, form
+}
+
+// transparent reports whether r is a mark that does not break the joining of the
+// letters around it, like the Arabic vowel signs.
+func transparent(r rune) bool {
+	return r >= '\u064B' && r <= '\u065F' || r == '\u0670'
+}
+
+// joining returns the joining type of the letter next to runes[i] in direction step,
+// skipping transparent marks.
+func joining(runes []rune, i, step int) byte {
+	for i += step; i >= 0 && i < len(runes); i += step {
+		if !transparent(runes[i]) {
+			t, _ := arabicForm(runes[i])
+			return t
+		}
+	}
+	return noForms
+}
+
+// shape replaces the Arabic letters of s with their contextual presentation forms,
+// joining each letter to its neighbours as the fonts do not.
+func shape(s string) string {
+	if !strings.ContainsFunc(s, func(r rune) bool { return r >= '\u0600' && r <= '\u06FF' }) {
+		return s
+	}
+	runes := []rune(s)
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		t, form := arabicForm(r)
+		if form == 0 {
+			b.WriteRune(r)
+			continue
+		}
+		prev := joining(runes, i, -1)
+		joinsPrev := t != joinNone && (prev == joinDual || prev == joinCause)
+
+		if r == '\u0644' {
+			next := i + 1
+			for next < len(runes) && transparent(runes[next]) {
+				next++
+			}
+			if next < len(runes) {
+				if lig, ok := lamAlef[runes[next]]; ok {
+					if joinsPrev {
+						lig++
+					}
+					b.WriteRune(lig)
+					b.WriteString(string(runes[i+1 : next]))
+					i = next
+					continue
+				}
+			}
+		}
+
+		next := joining(runes, i, 1)
+		joinsNext := t == joinDual && (next == joinRight || next == joinDual || next == joinCause)
+		switch {
+		case joinsPrev && joinsNext:
+			form += 3
+		case joinsPrev:
+			form++
+		case joinsNext:
+			form += 2
+		}
+		b.WriteRune(form)
+	}
+	return b.String()
+}
+
+// rightToLeft reports whether s has runes written from right to left.
+func rightToLeft(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool {
+		p, _ := bidi.LookupRune(r)
+		return p.Class() == bidi.R || p.Class() == bidi.AL
+	})
+}
+
+// bidiRun is a run of text at an embedding level: even levels are written from left to
+// right, odd levels from right to left.
+type bidiRun struct {
+	text  string
+	level int
+}
+
+// numberLen returns the length of the number s starts with.
+func numberLen(s string) int {
+	n := 0
+	for i, r := range s {
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.EN, bidi.AN, bidi.ET:
+			n = i + utf8.RuneLen(r)
+		case bidi.CS, bidi.ES:
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// visual returns the line s in the order its runes appear on screen in a paragraph
+// of direction dir, reversing right-to-left runs with their brackets mirrored.
+func visual(s string, dir types.Direction) string {
+	if !rightToLeft(s) {
+		return s
+	}
+	var p bidi.Paragraph
+	var err error
+	if dir == types.RTL {
+		_, err = p.SetString(s, bidi.DefaultDirection(bidi.RightToLeft))
+	} else {
+		_, err = p.SetString(s)
+	}
+	if err != nil {
+		return s
+	}
+	o, err := p.Order()
+	if err != nil {
+		return s
+	}
+
+	// The ordering only tells the direction of runs. Without explicit embeddings,
+	// left-to-right runs are at level 2 in right-to-left paragraphs, and so are the
+	// numbers following right-to-left text in left-to-right paragraphs.
+	var runs []bidiRun
+	for i := range o.NumRuns() {
+		run := o.Run(i)
+		text := run.String()
+		switch {
+		case run.Direction() == bidi.RightToLeft:
+			runs = append(runs, bidiRun{text, 1})
+		case dir == types.RTL:
+			runs = append(runs, bidiRun{text, 2})
+		default:
+			if i > 0 {
+				if n := numberLen(text); n > 0 {
+					runs = append(runs, bidiRun{text[:n], 2})
+					text = text[n:]
+				}
+			}
+			if text != "" {
+				runs = append(runs, bidiRun{text, 0})
+			}
+		}
+	}
+
+	// Reverse every sequence of runs at each level or higher, from the highest level
+	// down to 1, and the text of right-to-left runs.
+	for level := 2; level >= 1; level-- {
+		for i := 0; i < len(runs); {
+			if runs[i].level < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(runs) && runs[j].level >= level {
+				j++
+			}
+			slices.Reverse(runs[i:j])
+			i = j
+		}
+	}
+	var b strings.Builder
+	for _, run := range runs {
+		if run.level%2 == 1 {
+			run.text = bidi.ReverseString(run.text)
+		}
+		b.WriteString(run.text)
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package ogimage
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// maxPaletteSize is the largest number of colors of images encoded with a palette.
+const maxPaletteSize = 256
+
+// Encode writes m to w as a PNG image. Images with few colors, like those without
+// photos, are encoded with a palette, which is lossless and several times smaller.
+func Encode(w io.Writer, m image.Image) error {
+	if rgba, ok := m.(*image.RGBA); ok {
+		if p, ok := paletted(rgba); ok {
+			m = p
+		}
+	}
+	return png.Encode(w, m)
+}
+
+// EncodeWebP writes m to w as a lossless WebP image, which is smaller than the PNG
+// image for the platforms that accept WebP.
+func EncodeWebP(w io.Writer, m image.Image) error {
+	return webp.Encode(w, m, &webp.Options{Lossless: true})
+}
+
+// paletted returns m as a paletted image, or false if it has too many colors.
+func paletted(m *image.RGBA) (*image.Paletted, bool) {
+	b := m.Bounds()
+	index := make(map[uint32]uint8)
+	var palette color.Palette
+	p := image.NewPaletted(b, nil)
+	for y := 0; y < b.Dy(); y++ {
+		src := m.Pix[y*m.Stride : y*m.Stride+4*b.Dx()]
+		dst := p.Pix[y*p.Stride : y*p.Stride+b.Dx()]
+		for x := range dst {
+			s := src[4*x : 4*x+4 : 4*x+4]
+			c := uint32(s[0])<<24 | uint32(s[1])<<16 | uint32(s[2])<<8 | uint32(s[3])
+			i, ok := index[c]
+			if !ok {
+				if len(palette) == maxPaletteSize {
+					return nil, false
+				}
+				i = uint8(len(palette))
+				index[c] = i
+				palette = append(palette, color.RGBA{R: s[0], G: s[1], B: s[2], A: s[3]})
+			}
+			dst[x] = i
+		}
+	}
+	p.Palette = palette
+	return p, true
+}
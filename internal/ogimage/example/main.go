@@ -6,10 +6,21 @@ import (
 	"time"
 
 	"gosuda.org/website/internal/ogimage"
+	"gosuda.org/website/internal/types"
 )
 
 func main() {
-	img := ogimage.GenerateImage("GoSuda", "The 고수다 웹사이트", time.Now())
+	templates, err := ogimage.LoadTemplates("og")
+	if err != nil {
+		panic(err)
+	}
+
+	img := ogimage.GenerateImage(templates[ogimage.DefaultTemplate], &ogimage.Post{
+		Site:  "GoSuda",
+		Title: "The 고수다 웹사이트",
+		Date:  time.Now(),
+		Lang:  types.LangKorean,
+	})
 
 	f, err := os.Create("internal/ogimage/example/ogimage.png")
 	if err != nil {
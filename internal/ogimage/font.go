@@ -0,0 +1,219 @@
+package ogimage
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontDir is the directory the font files are loaded from.
+const fontDir = "fonts"
+
+// weight is the weight of a font family.
+type weight int
+
+const (
+	weightMedium weight = iota
+	weightThin
+)
+
+// fontSpec is a font of a family and the scripts it is preferred for.
+type fontSpec struct {
+	file    string
+	scripts []*unicode.RangeTable
+}
+
+// families lists the fonts of each weight in fallback order. The first font is the
+// primary font; the others draw the runes it has no glyphs for, preferably the font
+// listed for the script of the rune. Every font must be in fontDir.
+//
+// The Noto Sans CJK fonts are subsets of the Simplified Chinese and Japanese faces,
+// covering the ideographs of the first levels of GB 2312 and JIS X 0208 and of the
+// posts, and kana. The thin family has no fallbacks: thin texts the primary font
+// cannot draw are drawn in the medium weight, see textFace.
+var families = map[weight][]fontSpec{
+	weightMedium: {
+		{file: "IBMPlexSansKR-Medium.ttf", scripts: []*unicode.RangeTable{unicode.Hangul}},
+		{file: "DejaVuSans.ttf", scripts: []*unicode.RangeTable{unicode.Cyrillic, unicode.Greek, unicode.Hebrew, unicode.Arabic}},
+		{file: "NotoSansCJKsc-Bold.ttf", scripts: []*unicode.RangeTable{unicode.Han, unicode.Bopomofo}},
+		{file: "NotoSansCJKjp-Bold.ttf", scripts: []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}},
+	},
+	weightThin: {
+		{file: "IBMPlexSansKR-Thin.ttf", scripts: []*unicode.RangeTable{unicode.Hangul}},
+	},
+}
+
+var (
+	fontsMu sync.Mutex
+	fonts   = make(map[string]*sfnt.Font)
+)
+
+// LoadFonts loads the fonts of every family, so that a missing or invalid font fails
+// the build before images are drawn without it.
+func LoadFonts() error {
+	for _, specs := range families {
+		for _, spec := range specs {
+			if _, err := loadFont(spec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadFont returns the font of the spec, parsing its file once.
+func loadFont(spec fontSpec) (*sfnt.Font, error) {
+	fontsMu.Lock()
+	defer fontsMu.Unlock()
+	if f, ok := fonts[spec.file]; ok {
+		return f, nil
+	}
+	data, err := os.ReadFile(filepath.Join(fontDir, spec.file))
+	if err != nil {
+		return nil, err
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec.file, err)
+	}
+	fonts[spec.file] = f
+	return f, nil
+}
+
+// fallbackFace is a font.Face that draws every rune with the first font of a family
+// that has a glyph for it.
+type fallbackFace struct {
+	key   faceKey
+	fonts []*sfnt.Font
+	specs []fontSpec
+	faces []font.Face
+	buf   sfnt.Buffer
+	// chosen caches the index of the face of each rune.
+	chosen map[rune]int
+}
+
+type faceKey struct {
+	weight weight
+	size   float64
+}
+
+func newFallbackFace(key faceKey) *fallbackFace {
+	f := &fallbackFace{key: key, chosen: make(map[rune]int)}
+	for _, spec := range families[key.weight] {
+		// LoadFonts has checked the fonts.
+		sf, err := loadFont(spec)
+		if err != nil {
+			panic(err)
+		}
+		face, err := opentype.NewFace(sf, &opentype.FaceOptions{Size: key.size, DPI: 72})
+		if err != nil {
+			panic(err)
+		}
+		f.fonts = append(f.fonts, sf)
+		f.specs = append(f.specs, spec)
+		f.faces = append(f.faces, face)
+	}
+	return f
+}
+
+// has reports whether the i-th font has a glyph for r.
+func (f *fallbackFace) has(i int, r rune) bool {
+	g, err := f.fonts[i].GlyphIndex(&f.buf, r)
+	return err == nil && g != 0
+}
+
+// index returns the index of the face that draws r.
+func (f *fallbackFace) index(r rune) int {
+	if i, ok := f.chosen[r]; ok {
+		return i
+	}
+	i := f.find(r)
+	f.chosen[r] = i
+	return i
+}
+
+func (f *fallbackFace) find(r rune) int {
+	for i, spec := range f.specs {
+		if unicode.In(r, spec.scripts...) && f.has(i, r) {
+			return i
+		}
+	}
+	for i := range f.fonts {
+		if f.has(i, r) {
+			return i
+		}
+	}
+	return 0
+}
+
+// covers reports whether the primary font has glyphs for every rune of s.
+func (f *fallbackFace) covers(s string) bool {
+	for _, r := range s {
+		if f.index(r) != 0 || !f.has(0, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.faces[f.index(r)].Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.faces[f.index(r)].GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.faces[f.index(r)].GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	i := f.index(r0)
+	if i != f.index(r1) {
+		return 0
+	}
+	return f.faces[i].Kern(r0, r1)
+}
+
+// Metrics returns the metrics of the primary font, so that lines are spaced evenly.
+// The height is the font size, which lineSpacing is relative to, rather than the
+// line height of the font.
+func (f *fallbackFace) Metrics() font.Metrics {
+	m := f.faces[0].Metrics()
+	m.Height = fixed.Int26_6(f.key.size * 64)
+	return m
+}
+
+// facePools caches faces by weight and size, as faces are not safe for concurrent use.
+var facePools sync.Map
+
+func getFace(w weight, size float64) *fallbackFace {
+	key := faceKey{weight: w, size: size}
+	pool, _ := facePools.LoadOrStore(key, &sync.Pool{
+		New: func() any {
+			return newFallbackFace(key)
+		},
+	})
+	return pool.(*sync.Pool).Get().(*fallbackFace)
+}
+
+func putFace(f *fallbackFace) {
+	pool, _ := facePools.Load(f.key)
+	pool.(*sync.Pool).Put(f)
+}
@@ -0,0 +1,73 @@
+package ogimage
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+)
+
+// Version is incremented whenever the drawing of images changes, so that images
+// cached by previous builds are drawn again.
+const Version = 2
+
+// Key identifies the image of the post drawn by the template across builds. It
+// changes with the template, the data of the post, the hash of the data of its
+// avatar, the available fonts and Version.
+func Key(t *Template, p *Post, avatar string) string {
+	h := blake3.New()
+	field := func(s string) {
+		h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		h.WriteString(s)
+	}
+	field(strconv.Itoa(Version))
+	field(fontsKey())
+	h.Write(t.hash)
+	field(p.Site)
+	field(p.Title)
+	field(p.Date.Format(time.RFC3339))
+	field(p.Lang)
+	for _, author := range p.Authors {
+		field(author)
+	}
+	field("")
+	if p.Avatar == nil {
+		avatar = ""
+	}
+	field(avatar)
+	field(p.ReadingTime)
+	for _, tag := range p.Tags {
+		field(tag)
+	}
+	field("")
+	field(p.Package)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// fontsKey lists the font files of the families and their sizes, so that adding a
+// fallback font draws the images again.
+var fontsKey = sync.OnceValue(func() string {
+	var files []string
+	for _, specs := range families {
+		for _, spec := range specs {
+			files = append(files, spec.file)
+		}
+	}
+	slices.Sort(files)
+
+	var key []byte
+	for _, file := range slices.Compact(files) {
+		key = append(key, file...)
+		if info, err := os.Stat(filepath.Join(fontDir, file)); err == nil {
+			key = strconv.AppendInt(append(key, ':'), info.Size(), 10)
+		}
+		key = append(key, ';')
+	}
+	return string(key)
+})
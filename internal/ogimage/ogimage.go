@@ -3,72 +3,245 @@ package ogimage
 
 import (
 	"image"
-	"sync"
-	"time"
+	"slices"
+	"strings"
 
 	"github.com/fogleman/gg"
-	"golang.org/x/image/font"
+	"golang.org/x/image/draw"
+	"gosuda.org/website/internal/i18n"
+	"gosuda.org/website/internal/types"
 )
 
-// font_pool_IBMPlexSansKR_Medium is a sync.Pool for caching IBM Plex Mono Medium font faces.
-var font_pool_IBMPlexSansKR_Medium = sync.Pool{
-	New: func() interface{} {
-		f, err := gg.LoadFontFace("fonts/IBMPlexSansKR-Medium.ttf", 70)
-		if err != nil {
-			panic(err)
-		}
-		return f
-	},
-}
+// Dimensions of the generated images in pixels.
+const (
+	Width  = 1150
+	Height = 630
+)
 
-// font_pool_IBMPlexSansKR_Thin is a sync.Pool for caching IBM Plex Mono Thin font faces.
-var font_pool_IBMPlexSansKR_Thin = sync.Pool{
-	New: func() interface{} {
-		f, err := gg.LoadFontFace("fonts/IBMPlexSansKR-Thin.ttf", 60)
-		if err != nil {
-			panic(err)
-		}
-		return f
-	},
-}
+// Layout of texts. Titles shrink by titleSizeStep until they fit their box.
+const (
+	titleSizeStep = 4.0
+	lineSpacing   = 1.5
+	// badgePadding is the padding of badges around their text, relative to the font size.
+	badgePadding = 0.45
+	// tagGap is the gap between tags, relative to the font size.
+	tagGap = 0.4
+)
+
+// GenerateImage creates an Open Graph image of the post with the given template. The
+// template is mirrored for right-to-left languages.
+func GenerateImage(t *Template, p *Post) image.Image {
+	dir := types.Dir(p.Lang)
 
-// GenerateImage creates an Open Graph image with the given logo, title, and date.
-func GenerateImage(name string, title string, date time.Time) image.Image {
 	// Create a new context with the specified dimensions
-	ctx := gg.NewContext(1150, 630)
-	ctx.Clear()
+	ctx := gg.NewContext(Width, Height)
 
 	// Set background color
-	ctx.SetRGBA255(0xf2, 0xe7, 0xd5, 0xff)
+	ctx.SetColor(t.Background)
 	ctx.Clear()
 
-	// Draw inner rectangle
-	ctx.SetRGBA255(0x05, 0x15, 0x2a, 0xff)
-	ctx.DrawRectangle(20, 20, 1150-40, 630-40)
+	for i := range t.Elements {
+		e := &t.Elements[i]
+		if dir == types.RTL {
+			m := e.mirrored()
+			e = &m
+		}
+		switch e.Type {
+		case ElementRect:
+			drawRect(ctx, e)
+		case ElementTitle:
+			drawTitle(ctx, e, p.Title, dir)
+		case ElementText:
+			if s, ok := expand(e.Text, p); ok {
+				drawText(ctx, e, visual(shape(s), dir))
+			}
+		case ElementImage:
+			ctx.DrawImage(e.image, int(e.X), int(e.Y))
+		case ElementAvatar:
+			if p.Avatar != nil {
+				drawAvatar(ctx, e, p.Avatar)
+			}
+		case ElementTags:
+			drawTags(ctx, e, p.Tags, dir)
+		}
+	}
+
+	// Return the generated image
+	return ctx.Image()
+}
+
+// expand replaces the placeholders of s with the data of the post. It reports false if
+// a placeholder is empty.
+func expand(s string, p *Post) (string, bool) {
+	ok := true
+	var oldnew []string
+	for placeholder, value := range map[string]string{
+		"{site}":         p.Site,
+		"{title}":        p.Title,
+		"{date}":         i18n.FormatDate(p.Lang, p.Date),
+		"{author}":       strings.Join(p.Authors, ", "),
+		"{reading_time}": p.ReadingTime,
+		"{package}":      p.Package,
+	} {
+		if !strings.Contains(s, placeholder) {
+			continue
+		}
+		if value == "" {
+			ok = false
+		}
+		oldnew = append(oldnew, placeholder, value)
+	}
+	return strings.NewReplacer(oldnew...).Replace(s), ok
+}
+
+func drawRect(ctx *gg.Context, e *Element) {
+	ctx.SetColor(e.Color)
+	if e.Radius > 0 {
+		ctx.DrawRoundedRectangle(e.X, e.Y, e.Width, e.Height, e.Radius)
+	} else {
+		ctx.DrawRectangle(e.X, e.Y, e.Width, e.Height)
+	}
 	ctx.Fill()
+}
 
-	// Get and set IBM Plex Mono Medium font
-	_font_IBMPlexMono_Medium := font_pool_IBMPlexSansKR_Medium.Get().(font.Face)
-	defer font_pool_IBMPlexSansKR_Medium.Put(_font_IBMPlexMono_Medium)
-	ctx.SetFontFace(_font_IBMPlexMono_Medium)
+func drawTitle(ctx *gg.Context, e *Element, title string, dir types.Direction) {
+	face, lines := fitTitle(shape(title), e.Width, e.Height, e.Size, e.MinSize)
+	defer putFace(face)
+	for i, line := range lines {
+		lines[i] = visual(line, dir)
+	}
+	ctx.SetFontFace(face)
+	ctx.SetColor(e.Color)
 
-	// Draw title
-	ctx.SetRGBA255(0xf2, 0xe7, 0xd5, 0xff)
-	ctx.DrawStringWrapped(title, 40, 50, 0, 0, 1150-80, 1.5, gg.AlignLeft)
+	align := gg.AlignLeft
+	switch e.Align {
+	case "right":
+		align = gg.AlignRight
+	case "center":
+		align = gg.AlignCenter
+	}
+	ctx.DrawStringWrapped(strings.Join(lines, "\n"), e.X, e.Y, 0, 0, e.Width, lineSpacing, align)
+}
 
-	// Get and set IBM Plex Mono Thin font
-	_font_IBMPlexMono_Thin := font_pool_IBMPlexSansKR_Thin.Get().(font.Face)
-	defer font_pool_IBMPlexSansKR_Thin.Put(_font_IBMPlexMono_Thin)
-	ctx.SetFontFace(_font_IBMPlexMono_Thin)
+// textFace returns the face of texts of the element. Thin texts are drawn in the medium
+// weight if the thin font would be mixed with fallback fonts.
+func textFace(e *Element, s string) *fallbackFace {
+	if e.Weight == "thin" {
+		face := getFace(weightThin, e.Size)
+		if face.covers(s) {
+			return face
+		}
+		putFace(face)
+	}
+	return getFace(weightMedium, e.Size)
+}
+
+// badgeWidth returns the width of s drawn by the element, including the padding of
+// its badge.
+func badgeWidth(ctx *gg.Context, e *Element, s string) float64 {
+	w, _ := ctx.MeasureString(s)
+	if e.Background.A != 0 {
+		w += 2 * badgePadding * e.Size
+	}
+	return w
+}
 
-	// Draw date
-	d := date.Format("2006-01-02")
-	tx, _ := ctx.MeasureString(d)
-	ctx.DrawString(d, 1150-40-tx, 630-40)
+// anchor returns the left edge of content of width w drawn by the element.
+func anchor(e *Element, w float64) float64 {
+	switch e.Align {
+	case "right":
+		return e.X - w
+	case "center":
+		return e.X - w/2
+	}
+	return e.X
+}
 
-	// Draw logo
-	ctx.DrawString(name, 40, 630-40)
+// drawBadge draws s with its left edge at x, on a badge if the element has a background.
+func drawBadge(ctx *gg.Context, e *Element, face *fallbackFace, s string, x float64) {
+	if e.Background.A != 0 {
+		pad := badgePadding * e.Size
+		m := face.Metrics()
+		ascent, descent := float64(m.Ascent)/64, float64(m.Descent)/64
+		w, _ := ctx.MeasureString(s)
+		ctx.SetColor(e.Background)
+		ctx.DrawRoundedRectangle(x, e.Y-ascent-pad/2, w+2*pad, ascent+descent+pad, e.Radius)
+		ctx.Fill()
+		x += pad
+	}
+	ctx.SetColor(e.Color)
+	ctx.DrawString(s, x, e.Y)
+}
 
-	// Return the generated image
-	return ctx.Image()
+func drawText(ctx *gg.Context, e *Element, s string) {
+	face := textFace(e, s)
+	defer putFace(face)
+	ctx.SetFontFace(face)
+	drawBadge(ctx, e, face, s, anchor(e, badgeWidth(ctx, e, s)))
+}
+
+// drawTags draws the tags in a row in the writing direction, leaving out the tags that
+// do not fit in the width of the element, if it has one.
+func drawTags(ctx *gg.Context, e *Element, tags []string, dir types.Direction) {
+	if len(tags) == 0 {
+		return
+	}
+	tags = slices.Clone(tags)
+	for i, tag := range tags {
+		tags[i] = visual(shape(tag), dir)
+	}
+	face := textFace(e, strings.Join(tags, ""))
+	defer putFace(face)
+	ctx.SetFontFace(face)
+
+	gap := tagGap * e.Size
+	var fitting []string
+	var total float64
+	for _, tag := range tags {
+		w := badgeWidth(ctx, e, tag)
+		if len(fitting) > 0 {
+			w += gap
+		}
+		if e.Width > 0 && total+w > e.Width {
+			break
+		}
+		fitting = append(fitting, tag)
+		total += w
+	}
+
+	if dir == types.RTL {
+		slices.Reverse(fitting)
+	}
+	x := anchor(e, total)
+	for _, tag := range fitting {
+		drawBadge(ctx, e, face, tag, x)
+		x += badgeWidth(ctx, e, tag) + gap
+	}
+}
+
+// drawAvatar draws the avatar cropped to a circle in the box of the element.
+func drawAvatar(ctx *gg.Context, e *Element, avatar image.Image) {
+	ctx.DrawEllipse(e.X+e.Width/2, e.Y+e.Height/2, e.Width/2, e.Height/2)
+	ctx.Clip()
+	ctx.DrawImage(cover(avatar, e.Width, e.Height), int(e.X), int(e.Y))
+	ctx.ResetClip()
+}
+
+// cover scales m to fill a box of the given size, cropping the sides that overflow.
+func cover(m image.Image, width, height float64) image.Image {
+	w, h := int(width+0.5), int(height+0.5)
+	src := m.Bounds()
+	// Crop src to the aspect ratio of the box.
+	if src.Dx()*h > src.Dy()*w {
+		cw := src.Dy() * w / h
+		src.Min.X += (src.Dx() - cw) / 2
+		src.Max.X = src.Min.X + cw
+	} else {
+		ch := src.Dx() * h / w
+		src.Min.Y += (src.Dy() - ch) / 2
+		src.Max.Y = src.Min.Y + ch
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), m, src, draw.Src, nil)
+	return dst
 }
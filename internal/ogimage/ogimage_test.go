@@ -0,0 +1,256 @@
+package ogimage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chai2010/webp"
+	"gosuda.org/website/internal/types"
+)
+
+func TestSegments(t *testing.T) {
+	got := segments("Go 언어 入门。指南")
+	want := []string{"Go ", "언어 ", "入", "门。", "指", "南"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("segments = %q, want %q", got, want)
+	}
+}
+
+func TestFitTitle(t *testing.T) {
+	t.Chdir("../..")
+	const width, height, maxTitleSize, minTitleSize = 1070, 440, 70, 38
+
+	face, lines := fitTitle("Почему язык Go?", width, height, maxTitleSize, minTitleSize)
+	defer putFace(face)
+	if face.key.size != maxTitleSize || len(lines) != 1 {
+		t.Errorf("short title: size %v, %d lines, want %v, 1 line", face.key.size, len(lines), maxTitleSize)
+	}
+	for _, r := range "Почему" {
+		if face.index(r) == 0 {
+			t.Errorf("%q is drawn with the primary font", r)
+		}
+	}
+
+	long := strings.Repeat("Goroutines and channels ", 12)
+	face, lines = fitTitle(long, width, height, maxTitleSize, minTitleSize)
+	defer putFace(face)
+	if face.key.size >= maxTitleSize {
+		t.Errorf("long title was not shrunk")
+	}
+	for _, line := range lines {
+		if w := measure(face, line); w > width {
+			t.Errorf("line %q is %v wide", line, w)
+		}
+	}
+
+	face, lines = fitTitle(strings.Repeat(long, 4), width, height, maxTitleSize, minTitleSize)
+	defer putFace(face)
+	if face.key.size != minTitleSize || !strings.HasSuffix(lines[len(lines)-1], ellipsis) {
+		t.Errorf("overlong title: size %v, last line %q, want %v and an ellipsis", face.key.size, lines[len(lines)-1], minTitleSize)
+	}
+	if h := textHeight(face, len(lines), lineSpacing); h > height {
+		t.Errorf("overlong title is %v high", h)
+	}
+}
+
+func TestFonts(t *testing.T) {
+	t.Chdir("../..")
+	if err := LoadFonts(); err != nil {
+		t.Fatal(err)
+	}
+	face := getFace(weightMedium, 40)
+	defer putFace(face)
+	for _, r := range "为什么选择Go语言？Go言語の入門ガイド" {
+		if i := face.index(r); !face.has(i, r) {
+			t.Errorf("%q has no glyph", r)
+		}
+	}
+	if i := face.index('语'); face.specs[i].file != "NotoSansCJKsc-Bold.ttf" {
+		t.Errorf("Han is drawn with %s", face.specs[i].file)
+	}
+
+	families[weightThin] = append(families[weightThin], fontSpec{file: "Missing.ttf"})
+	defer func() { families[weightThin] = families[weightThin][:len(families[weightThin])-1] }()
+	if err := LoadFonts(); err == nil {
+		t.Error("LoadFonts ignores a missing font")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	p := &Post{Site: "GoSuda", Authors: []string{"Lemon Mint", "snowmerak"}}
+	if s, ok := expand("{site} by {author}", p); !ok || s != "GoSuda by Lemon Mint, snowmerak" {
+		t.Errorf("expand = %q, %v", s, ok)
+	}
+	if _, ok := expand("{package}", p); ok {
+		t.Error("expand of an empty placeholder succeeded")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	t.Chdir("../..")
+	templates, err := LoadTemplates("og")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := templates.Lookup("missing"); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Lookup(missing) = %v, want ErrUnknownTemplate", err)
+	}
+
+	avatar := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	post := &Post{
+		Site:        "GoSuda",
+		Title:       "Go Concurrency Starter Pack",
+		Date:        time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC),
+		Lang:        types.LangEnglish,
+		Authors:     []string{"Lemon Mint"},
+		Avatar:      avatar,
+		ReadingTime: "5 min read",
+		Tags:        []string{"go", "concurrency"},
+		Package:     "gosuda.org/randflake",
+	}
+	for name, tmpl := range templates {
+		if b := GenerateImage(tmpl, post).Bounds(); b.Dx() != Width || b.Dy() != Height {
+			t.Errorf("%s: image is %v", name, b)
+		}
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	for _, data := range []string{
+		"background: \"#fff\"\nelements: []\n",
+		"background: \"#ffffff\"\nelements:\n  - type: circle\n",
+		"background: \"#ffffff\"\nelements:\n  - type: text\n    size: 10\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "default.yaml"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTemplates(dir); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("LoadTemplates(%q) = %v, want ErrInvalidTemplate", data, err)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Chdir("../..")
+	templates, err := LoadTemplates("og")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := GenerateImage(templates[DefaultTemplate], &Post{Site: "GoSuda", Title: "Go Concurrency Starter Pack"})
+
+	var b bytes.Buffer
+	if err := Encode(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.(*image.Paletted); !ok {
+		t.Errorf("image is encoded as %T, want a palette", decoded)
+	}
+	for y := range Height {
+		for x := range Width {
+			if rgba(m, x, y) != rgba(decoded, x, y) {
+				t.Fatalf("pixel at %d,%d changed", x, y)
+			}
+		}
+	}
+
+	b.Reset()
+	if err := EncodeWebP(&b, m); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = webp.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := range Height {
+		for x := range Width {
+			if rgba(m, x, y) != rgba(decoded, x, y) {
+				t.Fatalf("pixel at %d,%d changed in the WebP image", x, y)
+			}
+		}
+	}
+}
+
+func rgba(m image.Image, x, y int) [4]uint32 {
+	r, g, b, a := m.At(x, y).RGBA()
+	return [4]uint32{r, g, b, a}
+}
+
+func TestKey(t *testing.T) {
+	tmpl := &Template{hash: []byte("template")}
+	post := &Post{Title: "Go", Authors: []string{"Lemon Mint"}, Tags: []string{"go"}}
+	key := Key(tmpl, post, "")
+	if Key(tmpl, post, "") != key {
+		t.Error("Key is not stable")
+	}
+	for name, changed := range map[string]string{
+		"title":    Key(tmpl, &Post{Title: "Go!", Authors: post.Authors, Tags: post.Tags}, ""),
+		"tags":     Key(tmpl, &Post{Title: "Go", Authors: post.Authors, Tags: []string{"go", "web"}}, ""),
+		"fields":   Key(tmpl, &Post{Title: "Go", Authors: []string{"Lemon Mint", "go"}}, ""),
+		"template": Key(&Template{hash: []byte("changed")}, post, ""),
+	} {
+		if changed == key {
+			t.Errorf("Key does not change with the %s", name)
+		}
+	}
+}
+
+func TestShape(t *testing.T) {
+	for input, want := range map[string]string{
+		// Initial, medial and final forms.
+		"بيت": "ﺑﻴﺖ",
+		// Lam-alef ligature after a joining letter, and a letter after a right-joining one.
+		"سلام": "ﺳﻼﻡ",
+		// Vowel signs do not break the joining.
+		"بَت":     "ﺑَﺖ",
+		"Go שלום": "Go שלום",
+	} {
+		if got := shape(input); got != want {
+			t.Errorf("shape(%+q) = %+q, want %+q", input, got, want)
+		}
+	}
+}
+
+func TestVisual(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		dir   types.Direction
+		want  string
+	}{
+		{"Go Concurrency (2025)", types.RTL, "Go Concurrency (2025)"},
+		{"שלום עולם", types.RTL, "םלוע םולש"},
+		{"שלום Go", types.RTL, "Go םולש"},
+		{"(שלום) עולם", types.RTL, "םלוע (םולש)"},
+		{"Go שלום", types.LTR, "Go םולש"},
+		{"Go שלום 1.26", types.LTR, "Go 1.26 םולש"},
+	} {
+		if got := visual(tc.input, tc.dir); got != tc.want {
+			t.Errorf("visual(%q, %s) = %q, want %q", tc.input, tc.dir, got, tc.want)
+		}
+	}
+}
+
+func TestMirrored(t *testing.T) {
+	title := Element{Type: ElementTitle, X: 40, Width: 700}
+	if m := title.mirrored(); m.X != Width-740 || m.Align != "right" {
+		t.Errorf("mirrored title = %+v", m)
+	}
+	text := Element{Type: ElementText, X: 1110, Align: "right"}
+	if m := text.mirrored(); m.X != 40 || m.Align != "left" {
+		t.Errorf("mirrored text = %+v", m)
+	}
+	if m := (Element{Type: ElementTags, X: 575, Align: "center"}).mirrored(); m.X != 575 || m.Align != "center" {
+		t.Errorf("mirrored tags = %+v", m)
+	}
+}
@@ -0,0 +1,268 @@
+package ogimage
+
+import (
+	_ "image/jpeg"
+	_ "image/png"
+
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/zeebo/blake3"
+	"gopkg.in/yaml.v3"
+	"gosuda.org/website/internal/types"
+)
+
+// DefaultTemplate is the template of posts that do not pick one.
+const DefaultTemplate = "default"
+
+var (
+	ErrInvalidTemplate = errors.New("invalid og image template")
+	ErrUnknownTemplate = errors.New("unknown og image template")
+)
+
+// Post holds the data an Open Graph image is drawn from.
+type Post struct {
+	Site  string
+	Title string
+	Date  time.Time
+	// Lang is the language of the title, used to format the date.
+	Lang types.Lang
+	// Authors are the display names of the authors, in byline order.
+	Authors []string
+	// Avatar is the avatar of the first author, or nil.
+	Avatar image.Image
+	// ReadingTime is the formatted reading time, or empty.
+	ReadingTime string
+	Tags        []string
+	// Package is the import path of the Go package of the post, or empty.
+	Package string
+}
+
+// Element types of templates.
+const (
+	ElementRect   = "rect"
+	ElementTitle  = "title"
+	ElementText   = "text"
+	ElementImage  = "image"
+	ElementAvatar = "avatar"
+	ElementTags   = "tags"
+)
+
+// Element is a shape, text or image drawn by a template.
+//
+// Rectangles, titles, images and avatars fill the box at X, Y of Width and Height.
+// Texts and tags are drawn on the baseline Y, starting at X or, when aligned right or
+// center, ending or centered at X. Text may contain the placeholders {site}, {title},
+// {date}, {author}, {reading_time} and {package}; texts with an empty placeholder are
+// not drawn, and neither are avatars and tags the post has none of.
+//
+// Templates are designed for left-to-right languages. For right-to-left languages,
+// elements are mirrored horizontally and the alignments of texts and tags swapped.
+type Element struct {
+	Type   string  `yaml:"type"`
+	X      float64 `yaml:"x"`
+	Y      float64 `yaml:"y"`
+	Width  float64 `yaml:"width,omitempty"`
+	Height float64 `yaml:"height,omitempty"`
+	// Radius rounds the corners of rectangles and text backgrounds.
+	Radius float64 `yaml:"radius,omitempty"`
+	Color  Color   `yaml:"color,omitempty"`
+	// Background draws texts and tags as badges.
+	Background Color  `yaml:"background,omitempty"`
+	Text       string `yaml:"text,omitempty"`
+	// Weight is the font weight of texts: medium (the default) or thin.
+	Weight string `yaml:"weight,omitempty"`
+	// Size is the font size of texts, and the largest font size of titles.
+	Size float64 `yaml:"size,omitempty"`
+	// MinSize is the smallest font size titles shrink to before they are truncated.
+	MinSize float64 `yaml:"min_size,omitempty"`
+	// Align is the alignment of titles, texts and tags: left (the default), right or center.
+	Align string `yaml:"align,omitempty"`
+	// Src is the path of the file drawn by images.
+	Src string `yaml:"src,omitempty"`
+
+	image image.Image
+}
+
+// Template is a declarative design of Open Graph images.
+type Template struct {
+	Name       string    `yaml:"-"`
+	Background Color     `yaml:"background"`
+	Elements   []Element `yaml:"elements"`
+
+	// hash is the hash of the template file and the images it draws.
+	hash []byte
+}
+
+// Has reports whether the template has an element of the given type.
+func (t *Template) Has(typ string) bool {
+	for _, e := range t.Elements {
+		if e.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Color is an RGB or RGBA color written as #rrggbb or #rrggbbaa.
+type Color struct {
+	color.NRGBA
+}
+
+func (c *Color) UnmarshalYAML(node *yaml.Node) error {
+	s := strings.TrimPrefix(node.Value, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 6 && len(s) != 8 {
+		return fmt.Errorf("%w: color %q at line %d", ErrInvalidTemplate, node.Value, node.Line)
+	}
+	if len(s) == 6 {
+		v = v<<8 | 0xff
+	}
+	c.NRGBA = color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}
+	return nil
+}
+
+// Templates holds the templates by name.
+type Templates map[string]*Template
+
+// LoadTemplates reads the templates from the YAML files in dir, named after the files.
+// The default template must exist.
+func LoadTemplates(dir string) (Templates, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(Templates)
+	for _, file := range files {
+		t, err := loadTemplate(file)
+		if err != nil {
+			return nil, err
+		}
+		templates[t.Name] = t
+	}
+	if templates[DefaultTemplate] == nil {
+		return nil, fmt.Errorf("%w: %s/%s.yaml is missing", ErrInvalidTemplate, dir, DefaultTemplate)
+	}
+	return templates, nil
+}
+
+func loadTemplate(file string) (*Template, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	h := blake3.New()
+	h.Write(data)
+	t := &Template{Name: strings.TrimSuffix(filepath.Base(file), ".yaml")}
+	err = yaml.Unmarshal(data, t)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, file, err)
+	}
+
+	for i := range t.Elements {
+		e := &t.Elements[i]
+		err := e.validate()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: element %d: %w", ErrInvalidTemplate, file, i+1, err)
+		}
+		if e.Type != ElementImage {
+			continue
+		}
+		src, err := os.ReadFile(e.Src)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, file, err)
+		}
+		h.Write(src)
+		m, _, err := image.Decode(bytes.NewReader(src))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s: %w", ErrInvalidTemplate, file, e.Src, err)
+		}
+		e.image = cover(m, e.Width, e.Height)
+	}
+	t.hash = h.Sum(nil)
+	return t, nil
+}
+
+func (e *Element) validate() error {
+	switch e.Type {
+	case ElementRect, ElementImage, ElementAvatar:
+		if e.Width <= 0 || e.Height <= 0 {
+			return errors.New("width and height are required")
+		}
+	case ElementTitle:
+		if e.Width <= 0 || e.Height <= 0 || e.Size <= 0 {
+			return errors.New("width, height and size are required")
+		}
+		if e.MinSize <= 0 || e.MinSize > e.Size {
+			e.MinSize = e.Size
+		}
+	case ElementText, ElementTags:
+		if e.Size <= 0 {
+			return errors.New("size is required")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", e.Type)
+	}
+
+	if e.Type != ElementImage && e.Type != ElementAvatar && e.Color.A == 0 {
+		return errors.New("color is required")
+	}
+	switch e.Weight {
+	case "", "medium", "thin":
+	default:
+		return fmt.Errorf("unknown weight %q", e.Weight)
+	}
+	switch e.Align {
+	case "", "left", "right", "center":
+	default:
+		return fmt.Errorf("unknown alignment %q", e.Align)
+	}
+	if e.Type == ElementImage && e.Src == "" {
+		return errors.New("src is required")
+	}
+	return nil
+}
+
+// mirrored returns the element flipped horizontally, as drawn for right-to-left languages.
+func (e Element) mirrored() Element {
+	switch e.Align {
+	case "", "left":
+		e.Align = "right"
+	case "right":
+		e.Align = "left"
+	}
+	switch e.Type {
+	case ElementText, ElementTags:
+		e.X = Width - e.X
+	case ElementImage:
+		e.X = Width - e.X - float64(e.image.Bounds().Dx())
+	default:
+		e.X = Width - e.X - e.Width
+	}
+	return e
+}
+
+// Lookup returns the template with the given name, or the default template if name is
+// empty.
+func (ts Templates) Lookup(name string) (*Template, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	t, ok := ts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+	return t, nil
+}
@@ -0,0 +1,116 @@
+package ogimage
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+)
+
+// ellipsis ends titles that do not fit even at the smallest size.
+const ellipsis = "…"
+
+// breaksAround reports whether a line may break before and after r, as in scripts
+// written without spaces between words.
+func breaksAround(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Bopomofo)
+}
+
+// segments splits s into the units that are kept on one line: words with their
+// trailing spaces, and single CJK characters with their trailing punctuation.
+func segments(s string) []string {
+	var segs []string
+	start := 0
+	prev := rune(-1)
+	for i, r := range s {
+		if i > start {
+			brk := unicode.IsSpace(prev) && !unicode.IsSpace(r) ||
+				breaksAround(r) ||
+				breaksAround(prev) && !unicode.IsPunct(r) && !unicode.IsSpace(r)
+			if brk {
+				segs = append(segs, s[start:i])
+				start = i
+			}
+		}
+		prev = r
+	}
+	if start < len(s) {
+		segs = append(segs, s[start:])
+	}
+	return segs
+}
+
+func measure(face font.Face, s string) float64 {
+	return float64(font.MeasureString(face, s)) / 64
+}
+
+// wrap breaks s into lines no wider than width. Segments wider than a line are
+// broken between runes.
+func wrap(face font.Face, s string, width float64) []string {
+	var lines []string
+	var line string
+	for _, seg := range segments(strings.Join(strings.Fields(s), " ")) {
+		if measure(face, strings.TrimRight(line+seg, " ")) <= width {
+			line += seg
+			continue
+		}
+		if line != "" {
+			lines = append(lines, strings.TrimRight(line, " "))
+			line = ""
+		}
+		for _, r := range seg {
+			if line != "" && measure(face, strings.TrimRight(line+string(r), " ")) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	if line = strings.TrimRight(line, " "); line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncate shortens the last of lines to n lines, ending it with an ellipsis that
+// fits within width.
+func truncate(face font.Face, lines []string, n int, width float64) []string {
+	if len(lines) <= n {
+		return lines
+	}
+	lines = lines[:n]
+	last := []rune(lines[n-1])
+	for len(last) > 0 && measure(face, strings.TrimRight(string(last), " ")+ellipsis) > width {
+		last = last[:len(last)-1]
+	}
+	lines[n-1] = strings.TrimRight(string(last), " ") + ellipsis
+	return lines
+}
+
+// textHeight returns the height of n lines of the face with the given line spacing,
+// as gg.Context.DrawStringWrapped lays them out.
+func textHeight(face font.Face, n int, spacing float64) float64 {
+	h := float64(face.Metrics().Height) / 64
+	return float64(n)*h*spacing - (spacing-1)*h
+}
+
+// fitTitle returns the largest title face from maxSize down to minSize whose wrapped
+// lines fit in width and height, and the lines. Titles that do not fit at minSize are
+// truncated. The face must be returned with putFace.
+func fitTitle(title string, width, height, maxSize, minSize float64) (*fallbackFace, []string) {
+	for size := maxSize; ; size = max(size-titleSizeStep, minSize) {
+		face := getFace(weightMedium, size)
+		lines := wrap(face, title, width)
+		if textHeight(face, len(lines), lineSpacing) <= height {
+			return face, lines
+		}
+		if size == minSize {
+			n := 1
+			for textHeight(face, n+1, lineSpacing) <= height {
+				n++
+			}
+			return face, truncate(face, lines, n, width)
+		}
+		putFace(face)
+	}
+}
@@ -0,0 +1,303 @@
+// Package responsive generates resized and re-encoded variants of local images, so
+// that browsers can download the smallest image that fills its place on the page.
+//
+// Variants are encoded in the format of the original image and in WebP, with libwebp.
+// AVIF is out of scope: there is no maintained Go AVIF encoder, and the libavif
+// bindings need libaom on every machine that builds the site, while WebP is already
+// supported by every browser the site targets.
+package responsive
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/chai2010/webp"
+	"github.com/rs/zerolog/log"
+	"github.com/zeebo/blake3"
+	"golang.org/x/image/draw"
+	"gosuda.org/website/internal/imagemeta"
+)
+
+// Version is incremented whenever the generated variants change, so that cached
+// variants are generated again.
+const Version = 2
+
+// cacheDir holds generated variants across builds, keyed by the content hash of
+// their image.
+const cacheDir = ".cache/images"
+
+// Sizes is the sizes attribute of responsive images: posts are at most 1120 pixels
+// wide, with a margin of 1rem on either side.
+const Sizes = "(min-width: 1152px) 1120px, calc(100vw - 2rem)"
+
+// widths are the widths of the resized variants. Images are never enlarged.
+var widths = []int{480, 800, 1120, 1680}
+
+const (
+	jpegQuality = 85
+	webpQuality = 80
+)
+
+var ErrUnknownImage = errors.New("image is unknown")
+
+// Format is an image format variants are encoded in.
+type Format struct {
+	Type string
+	Ext  string
+}
+
+var (
+	FormatJPEG = Format{Type: "image/jpeg", Ext: ".jpg"}
+	FormatPNG  = Format{Type: "image/png", Ext: ".png"}
+	FormatWebP = Format{Type: "image/webp", Ext: ".webp"}
+)
+
+// Candidate is a variant of an image and its width in pixels.
+type Candidate struct {
+	URL   string
+	Width int
+}
+
+// Source holds the variants of an image in one format.
+type Source struct {
+	Format     Format
+	Candidates []Candidate
+}
+
+// srcsetEscaper escapes the characters that separate the candidates of a srcset attribute.
+var srcsetEscaper = strings.NewReplacer(" ", "%20", ",", "%2C")
+
+// Srcset returns the srcset attribute of the candidates.
+func (s *Source) Srcset() string {
+	var b strings.Builder
+	for i, c := range s.Candidates {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(srcsetEscaper.Replace(c.URL) + " " + strconv.Itoa(c.Width) + "w")
+	}
+	return b.String()
+}
+
+// Set holds the variants of an image.
+type Set struct {
+	// Alternates are the variants in formats preferred to the original one.
+	Alternates []Source
+	// Fallback are the variants in the format of the original image, including itself.
+	Fallback Source
+}
+
+// Processor generates the variants of local images.
+type Processor struct {
+	meta      *imagemeta.Cache
+	publicDir string
+	distDir   string
+
+	mu   sync.Mutex
+	sets map[string]*Set
+}
+
+// New returns a processor that reads images from publicDir and writes their variants
+// next to them in distDir.
+func New(meta *imagemeta.Cache, publicDir, distDir string) *Processor {
+	return &Processor{
+		meta:      meta,
+		publicDir: publicDir,
+		distDir:   distDir,
+		sets:      make(map[string]*Set),
+	}
+}
+
+// Variants returns the variants of the image at src, or nil if it has none.
+func (p *Processor) Variants(src string) *Set {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sets[src]
+}
+
+// Process generates the variants of the local images in sources in parallel.
+// Images that cannot be processed are reported as warnings and keep no variants.
+func (p *Processor) Process(sources []string) {
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range queue {
+				set, err := p.process(src)
+				if err != nil {
+					log.Warn().Err(err).Str("src", src).Msg("failed to generate image variants")
+					continue
+				}
+				if set == nil {
+					continue
+				}
+				p.mu.Lock()
+				p.sets[src] = set
+				p.mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		if seen[src] || !strings.HasPrefix(src, "/") || formatOf(src) == nil {
+			continue
+		}
+		seen[src] = true
+		queue <- src
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// formatOf returns the format of the variants of an image in the same format, or
+// nil if the image gets no variants, e.g. because it is animated or a vector image.
+func formatOf(src string) *Format {
+	switch strings.ToLower(path.Ext(src)) {
+	case ".jpg", ".jpeg":
+		return &FormatJPEG
+	case ".png":
+		return &FormatPNG
+	case ".webp":
+		return &FormatWebP
+	}
+	return nil
+}
+
+// variant is a variant of an image to generate.
+type variant struct {
+	format Format
+	width  int
+	url    string
+}
+
+func (p *Processor) process(src string) (*Set, error) {
+	info, ok := p.meta.Info(src)
+	if !ok {
+		return nil, ErrUnknownImage
+	}
+	original := *formatOf(src)
+
+	data, err := os.ReadFile(filepath.Join(p.publicDir, filepath.FromSlash(src)))
+	if err != nil {
+		return nil, err
+	}
+	m, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	formats := []Format{original}
+	if original != FormatWebP {
+		formats = append(formats, FormatWebP)
+	}
+
+	stem := strings.TrimSuffix(src, path.Ext(src))
+	set := &Set{Fallback: Source{Format: original}}
+	var variants []variant
+	for _, f := range formats {
+		source := Source{Format: f}
+		for _, w := range widths {
+			if w >= info.Width {
+				break
+			}
+			v := variant{format: f, width: w, url: stem + "-" + strconv.Itoa(w) + "w" + f.Ext}
+			variants = append(variants, v)
+			source.Candidates = append(source.Candidates, Candidate{URL: v.url, Width: w})
+		}
+		if f == original {
+			source.Candidates = append(source.Candidates, Candidate{URL: src, Width: info.Width})
+			set.Fallback = source
+			continue
+		}
+		v := variant{format: f, width: info.Width, url: stem + "-" + strconv.Itoa(info.Width) + "w" + f.Ext}
+		variants = append(variants, v)
+		source.Candidates = append(source.Candidates, Candidate{URL: v.url, Width: info.Width})
+		set.Alternates = append(set.Alternates, source)
+	}
+
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	for _, v := range variants {
+		err := p.generate(m, info.Hash, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// generate writes a variant of the image m to the dist directory, reusing the variant
+// generated by a previous build of the same image.
+func (p *Processor) generate(m image.Image, hash string, v variant) error {
+	sum := blake3.Sum256([]byte(strconv.Itoa(Version) + "\x00" + hash + "\x00" + strconv.Itoa(v.width) + v.format.Ext))
+	cached := filepath.Join(cacheDir, hex.EncodeToString(sum[:16])+v.format.Ext)
+	dst := filepath.Join(p.distDir, filepath.FromSlash(v.url))
+
+	data, err := os.ReadFile(cached)
+	if err != nil {
+		data, err = encode(m, v)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(cacheDir, 0755)
+		if err == nil {
+			err = os.WriteFile(cached, data, 0644)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("path", cached).Msg("failed to cache image variant")
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// encode resizes m to the width of the variant and encodes it in its format.
+func encode(m image.Image, v variant) ([]byte, error) {
+	b := m.Bounds()
+	if v.width != b.Dx() {
+		h := max((b.Dy()*v.width+b.Dx()/2)/b.Dx(), 1)
+		dst := image.NewRGBA(image.Rect(0, 0, v.width, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), m, b, draw.Src, nil)
+		m = dst
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch v.format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, m, &jpeg.Options{Quality: jpegQuality})
+	case FormatPNG:
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(&buf, m)
+	case FormatWebP:
+		err = webp.Encode(&buf, m, &webp.Options{Quality: webpQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
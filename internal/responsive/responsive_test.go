@@ -0,0 +1,116 @@
+package responsive
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gosuda.org/website/internal/imagemeta"
+	"gosuda.org/website/internal/types"
+)
+
+func writePNG(t *testing.T, path string, w, h int, c color.Color) {
+	t.Helper()
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range m.Pix {
+		m.Pix[i] = 0xff
+	}
+	m.Set(0, 0, c)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	t.Chdir(t.TempDir())
+	publicDir, distDir := t.TempDir(), t.TempDir()
+	writePNG(t, filepath.Join(publicDir, "wide.png"), 900, 300, color.Black)
+	writePNG(t, filepath.Join(publicDir, "alpha.png"), 600, 200, color.Transparent)
+	writePNG(t, filepath.Join(publicDir, "small.png"), 100, 100, color.Black)
+
+	meta := imagemeta.New(make(map[string]*types.ImageInfo), publicDir, true)
+	p := New(meta, publicDir, distDir)
+	p.Process([]string{"/wide.png", "/alpha.png", "/small.png", "/icon.svg", "https://example.com/a.png"})
+
+	set := p.Variants("/wide.png")
+	if set == nil {
+		t.Fatal("no variants of /wide.png")
+	}
+	if got, want := set.Fallback.Srcset(), "/wide-480w.png 480w, /wide-800w.png 800w, /wide.png 900w"; got != want {
+		t.Errorf("fallback srcset = %q, want %q", got, want)
+	}
+	if len(set.Alternates) != 1 || set.Alternates[0].Format != FormatWebP {
+		t.Fatalf("alternates = %+v, want one WebP source", set.Alternates)
+	}
+	if got, want := set.Alternates[0].Srcset(), "/wide-480w.webp 480w, /wide-800w.webp 800w, /wide-900w.webp 900w"; got != want {
+		t.Errorf("webp srcset = %q, want %q", got, want)
+	}
+	for _, name := range []string{"wide-480w.png", "wide-800w.png", "wide-480w.webp", "wide-800w.webp", "wide-900w.webp"} {
+		f, err := os.Open(filepath.Join(distDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, _, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := (300*cfg.Width + 450) / 900; cfg.Height != want {
+			t.Errorf("%s is %dx%d, want height %d", name, cfg.Width, cfg.Height, want)
+		}
+	}
+
+	// Lossy WebP variants keep the transparency of the image.
+	if set := p.Variants("/alpha.png"); set == nil || len(set.Alternates) != 1 {
+		t.Errorf("variants of /alpha.png = %+v, want a WebP alternate", set)
+	}
+	f, err := os.Open(filepath.Join(distDir, "alpha-600w.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, a := m.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("alpha-600w.webp is opaque at (0, 0): alpha %d", a)
+	}
+	// Images smaller than every width are only re-encoded.
+	if set := p.Variants("/small.png"); set == nil || set.Fallback.Srcset() != "/small.png 100w" ||
+		len(set.Alternates) != 1 || set.Alternates[0].Srcset() != "/small-100w.webp 100w" {
+		t.Errorf("variants of /small.png = %+v, want a single WebP alternate", set)
+	}
+	for _, src := range []string{"/icon.svg", "https://example.com/a.png"} {
+		if set := p.Variants(src); set != nil {
+			t.Errorf("variants of %s = %+v, want none", src, set)
+		}
+	}
+
+	// A second build reuses the cached variants.
+	cached, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	distDir = t.TempDir()
+	New(meta, publicDir, distDir).Process([]string{"/wide.png"})
+	again, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(cached, again, func(a, b os.DirEntry) bool { return a.Name() == b.Name() }) {
+		t.Error("second build changed the cache")
+	}
+	if _, err := os.Stat(filepath.Join(distDir, "wide-800w.webp")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,64 @@
+package translate
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lemon-mint/coord/llm"
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/diagram"
+)
+
+// diagramBlock splits a block consisting of a single fenced diagram code block into its
+// opening fence line, diagram source and closing lines.
+func diagramBlock(block string) (lang, open, source, close string, ok bool) {
+	lines := strings.SplitAfter(block, "\n")
+	var f fence
+	info, ok := f.open(lines[0])
+	if !ok {
+		return "", "", "", "", false
+	}
+	lang, _, _ = strings.Cut(info, " ")
+	if !diagram.IsLanguage(lang) {
+		return "", "", "", "", false
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if !f.close(lines[i]) {
+			continue
+		}
+		for _, line := range lines[i+1:] {
+			if !isBlank(line) {
+				return "", "", "", "", false
+			}
+		}
+		return lang, lines[0], strings.Join(lines[1:i], ""), strings.Join(lines[i:], ""), true
+	}
+	return "", "", "", "", false
+}
+
+// translateDiagram translates the labels of a diagram, leaving its syntax unchanged.
+func translateDiagram(ctx context.Context, l llm.Model, lang, source, targetLanguage string) (string, error) {
+	labels, err := diagram.Labels(lang, source)
+	if err != nil || len(labels) == 0 {
+		return source, nil
+	}
+
+	var translated []string
+	for retry := 0; ; retry++ {
+		text, err := translateChunk(ctx, l, strings.Join(labels, "\n"), targetLanguage)
+		if err == nil {
+			translated = strings.Split(strings.TrimSpace(text), "\n")
+			if len(translated) == len(labels) {
+				break
+			}
+			err = ErrFailedToTranslate
+		}
+		if retry >= 3 {
+			return "", err
+		}
+		log.Debug().Int("retry", retry+1).Str("language", lang).Msg("retrying diagram labels")
+	}
+
+	return diagram.Relabel(lang, source, translated)
+}
@@ -0,0 +1,168 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrStructureMismatch = errors.New("translation does not preserve the markdown structure")
+
+var (
+	admonitionRegex     = regexp.MustCompile(`(?m)^[ \t]*(?:>[ \t]*)+\[!([A-Za-z]+)\][ \t]*$`)
+	footnoteLabelRegex  = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+	definitionLineRegex = regexp.MustCompile(`(?m)^[ \t]*: `)
+	fenceRegex          = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	tabFenceRegex       = regexp.MustCompile(`(?:^|\s)tab=`)
+)
+
+// fence tracks fenced code blocks while scanning markdown line by line.
+type fence struct {
+	marker string
+	// tab reports whether the last fenced code block was labeled with a tab attribute.
+	tab bool
+}
+
+// open reports whether line opens a fenced code block, and returns its info string.
+func (f *fence) open(line string) (string, bool) {
+	m := fenceRegex.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil || (m[1][0] == '`' && strings.Contains(m[2], "`")) {
+		return "", false
+	}
+	f.marker = m[1]
+	info := strings.TrimSpace(m[2])
+	f.tab = tabFenceRegex.MatchString(info)
+	return info, true
+}
+
+// close reports whether line closes the open fenced code block.
+func (f *fence) close(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, f.marker) || strings.Trim(trimmed, f.marker[:1]) != "" {
+		return false
+	}
+	f.marker = ""
+	return true
+}
+
+func (f *fence) inside() bool {
+	return f.marker != ""
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// continuesBlock reports whether line, following a blank line, still belongs to the previous block:
+// indented continuations of lists and footnotes, further definitions of a definition list, and
+// the next tab of a tabbed code block.
+func continuesBlock(line string, f *fence) bool {
+	if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, ": ") {
+		return true
+	}
+	if !f.tab {
+		return false
+	}
+	next := *f
+	_, ok := next.open(line)
+	return ok && next.tab
+}
+
+// splitBlocks splits markdown into blocks separated by blank lines, keeping the separators.
+// Fenced code blocks, and markdown structures that span blank lines, are never split.
+// Joining the blocks returns the input.
+func splitBlocks(input string) []string {
+	var blocks []string
+	var current strings.Builder
+	var f fence
+	blank := false
+
+	for _, line := range strings.SplitAfter(input, "\n") {
+		if line == "" {
+			continue
+		}
+
+		if f.inside() {
+			f.close(line)
+			current.WriteString(line)
+			continue
+		}
+
+		if isBlank(line) {
+			blank = current.Len() > 0
+			current.WriteString(line)
+			continue
+		}
+
+		if blank && !continuesBlock(line, &f) {
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+		blank = false
+
+		if _, ok := f.open(line); !ok {
+			f.tab = false
+		}
+		current.WriteString(line)
+	}
+
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+	return blocks
+}
+
+// fenceInfos returns the info strings of the fenced code blocks of markdown, in order.
+func fenceInfos(markdown string) []string {
+	var infos []string
+	var f fence
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		if f.inside() {
+			f.close(line)
+			continue
+		}
+		if info, ok := f.open(line); ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// footnoteLabels returns the sorted footnote labels referenced or defined in markdown.
+func footnoteLabels(markdown string) []string {
+	var labels []string
+	for _, m := range footnoteLabelRegex.FindAllStringSubmatch(markdown, -1) {
+		labels = append(labels, m[1])
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// admonitionKinds returns the upper case kinds of the admonition markers of markdown, in order.
+func admonitionKinds(markdown string) []string {
+	var kinds []string
+	for _, m := range admonitionRegex.FindAllStringSubmatch(markdown, -1) {
+		kinds = append(kinds, strings.ToUpper(m[1]))
+	}
+	return kinds
+}
+
+// CheckStructure returns an error wrapping ErrStructureMismatch if translated does not keep the
+// admonition markers, footnote labels, code block info strings and definition lists of original.
+func CheckStructure(original, translated string) error {
+	if !slices.Equal(admonitionKinds(original), admonitionKinds(translated)) {
+		return fmt.Errorf("%w: admonition markers differ", ErrStructureMismatch)
+	}
+	if !slices.Equal(footnoteLabels(original), footnoteLabels(translated)) {
+		return fmt.Errorf("%w: footnote labels differ", ErrStructureMismatch)
+	}
+	if !slices.Equal(fenceInfos(original), fenceInfos(translated)) {
+		return fmt.Errorf("%w: code block info strings differ", ErrStructureMismatch)
+	}
+	if len(definitionLineRegex.FindAllString(original, -1)) != len(definitionLineRegex.FindAllString(translated, -1)) {
+		return fmt.Errorf("%w: definition lists differ", ErrStructureMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package translate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const structureSource = "# Title\n\n" +
+	"> [!NOTE]\n> Read this first.\n\n" +
+	"Go has goroutines[^1].\n\n" +
+	"```go tab=Server\nfunc main() {\n\n\tserve()\n}\n```\n\n" +
+	"```go tab=Client\nfunc main() {}\n```\n\n" +
+	"Term\n: First definition.\n\n: Second definition.\n\n" +
+	"[^1]: Lightweight threads.\n\n    Managed by the runtime.\n\n" +
+	"Last paragraph.\n"
+
+func TestSplitBlocks(t *testing.T) {
+	blocks := splitBlocks(structureSource)
+	if joined := strings.Join(blocks, ""); joined != structureSource {
+		t.Fatalf("joined blocks do not match the input:\n%q", joined)
+	}
+
+	expected := []string{"# Title", "> [!NOTE]", "Go has", "```go tab=Server", "Term", "[^1]:", "Last paragraph."}
+	if len(blocks) != len(expected) {
+		t.Fatalf("expected %d blocks, got %d: %q", len(expected), len(blocks), blocks)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(blocks[i], prefix) {
+			t.Errorf("expected block %d to start with %q, got %q", i, prefix, blocks[i])
+		}
+	}
+	if !strings.Contains(blocks[3], "tab=Client") {
+		t.Errorf("expected tabbed code blocks to stay together, got %q", blocks[3])
+	}
+}
+
+func TestCheckStructure(t *testing.T) {
+	translated := strings.NewReplacer(
+		"Read this first.", "먼저 읽어 주세요.",
+		"Last paragraph.", "마지막 문단.",
+	).Replace(structureSource)
+	if err := CheckStructure(structureSource, translated); err != nil {
+		t.Errorf("expected matching structure, got %v", err)
+	}
+
+	broken := []string{
+		strings.Replace(translated, "[!NOTE]", "[!참고]", 1),
+		strings.Replace(translated, "[^1]:", "[^주1]:", 1),
+		strings.Replace(translated, "tab=Client", "tab=클라이언트", 1),
+		strings.Replace(translated, ": Second", "Second", 1),
+	}
+	for _, b := range broken {
+		if err := CheckStructure(structureSource, b); !errors.Is(err, ErrStructureMismatch) {
+			t.Errorf("expected ErrStructureMismatch, got %v", err)
+		}
+	}
+}
+
+func TestDiagramBlock(t *testing.T) {
+	block := "```mermaid\ngraph TD\n  A[Start] --> B[End]\n```\n\n"
+	lang, open, source, close, ok := diagramBlock(block)
+	if !ok || lang != "mermaid" || source != "graph TD\n  A[Start] --> B[End]\n" {
+		t.Fatalf("unexpected diagram block %q %q %v", lang, source, ok)
+	}
+	if open+source+close != block {
+		t.Errorf("expected the parts to join to the block, got %q", open+source+close)
+	}
+
+	for _, b := range []string{"```go\nfunc main() {}\n```\n", "```mermaid\ngraph TD\n```\nText after.\n"} {
+		if _, _, _, _, ok := diagramBlock(b); ok {
+			t.Errorf("expected %q not to be a diagram block", b)
+		}
+	}
+}
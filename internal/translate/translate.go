@@ -33,6 +33,7 @@ Translate the following text into <TARGET_LANGUAGE>, adhering to these guideline
 	m. Retain the start token and the end token.
 	n. Never use word "delve", "deepen" and "elara".
 	o. Preserve every whitespace and other formatting syntax unchanged.
+	p. Keep admonition markers such as "> [!NOTE]", footnote labels such as "[^1]", definition list markers (": ") and the info strings of code blocks (e.g. "go tab=Server") exactly as they are.
 
 Do not include any additional commentary or explanations.
 Begin your translation now, translate the following text into <TARGET_LANGUAGE>.
@@ -44,7 +45,8 @@ INPUT_TEXT:
 const CHUNK_SIZE = 24576
 
 // chunkMarkdown splits the input text into smaller chunks, ensuring that each chunk does not exceed the token limit.
-// It handles code blocks and tries to split paragraphs at natural breakpoints (e.g., periods) to preserve the original formatting.
+// Fenced code blocks and other markdown structures are kept whole (see splitBlocks), and overlong
+// blocks are split at natural breakpoints (e.g., periods) to preserve the original formatting.
 // The resulting chunks are returned as a slice of strings.
 func chunkMarkdown(input string) []string {
 	tok, err := tokenizer.New("gemini-1.5-flash")
@@ -55,24 +57,18 @@ func chunkMarkdown(input string) []string {
 	var chunks []string
 	var currentChunk strings.Builder
 	currentTokens := 0
-	inCodeBlock := false
 
-	// Split the input into paragraphs, keeping the delimiters
-	paragraphs := strings.SplitAfter(input, "\n\n")
+	// Split the input into blocks, keeping the delimiters
+	paragraphs := splitBlocks(input)
 
 	for _, paragraph := range paragraphs {
-		// Check if this paragraph is a code block
-		if strings.HasPrefix(paragraph, "```") || strings.HasSuffix(paragraph, "```") {
-			inCodeBlock = !inCodeBlock
-		}
-
 		paragraphTokens, err := tok.CountTokens(genai.Text(paragraph))
 		if err != nil {
 			log.Fatal().Err(err).Msg("failed to count tokens")
 		}
 
-		// If adding this paragraph would exceed the token limit or it's a code block
-		if currentTokens+int(paragraphTokens.TotalTokens) > CHUNK_SIZE || inCodeBlock {
+		// If adding this paragraph would exceed the token limit
+		if currentTokens+int(paragraphTokens.TotalTokens) > CHUNK_SIZE {
 			// If the current chunk is not empty, add it to chunks
 			if currentChunk.Len() > 0 {
 				chunks = append(chunks, currentChunk.String())
@@ -86,17 +82,17 @@ func chunkMarkdown(input string) []string {
 				for _, line := range lines {
 					lineTokens, _ := tok.CountTokens(genai.Text(line))
 					if int(lineTokens.TotalTokens) > CHUNK_SIZE {
-						// Split by rune count or "."
+						// Split by rune count or at sentence ends
 						runes := []rune(line)
 						for len(runes) > 0 {
 							splitIndex := CHUNK_SIZE
 							if splitIndex > len(runes) {
 								splitIndex = len(runes)
 							}
-							// Try to split at the last period before CHUNK_SIZE runes
-							lastPeriod := strings.LastIndex(string(runes[:splitIndex]), ".")
-							if lastPeriod > 0 {
-								splitIndex = lastPeriod + 1
+							// Try to split at the last sentence end before CHUNK_SIZE runes
+							lastEnd := lastSentenceEnd(runes[:splitIndex])
+							if lastEnd > 0 {
+								splitIndex = lastEnd + 1
 							}
 							chunks = append(chunks, string(runes[:splitIndex]))
 							runes = runes[splitIndex:]
@@ -138,6 +134,20 @@ func chunkMarkdown(input string) []string {
 	return finalChunks
 }
 
+// sentenceEnds are the punctuation marks ending sentences in Latin, CJK, Arabic
+// and Devanagari text.
+const sentenceEnds = ".!?。！？؟۔।"
+
+// lastSentenceEnd returns the index of the last rune ending a sentence, or -1.
+func lastSentenceEnd(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if strings.ContainsRune(sentenceEnds, runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
 func groupChunks(chunks []string, maxTokens int) [][]string {
 	var groupedChunks [][]string
 	var currentGroup []string
@@ -202,7 +212,48 @@ func translateChunk(ctx context.Context, l llm.Model, chunk string, targetLangua
 	return "", ErrFailedToTranslate
 }
 
+// Translate translates markdown into targetLanguage. Only the labels of diagrams are translated.
 func Translate(ctx context.Context, l llm.Model, input, targetLanguage string) (string, error) {
+	var translated, text strings.Builder
+	flush := func() error {
+		if text.Len() == 0 {
+			return nil
+		}
+		t, err := translateText(ctx, l, text.String(), targetLanguage)
+		if err != nil {
+			return err
+		}
+		translated.WriteString(t)
+		text.Reset()
+		return nil
+	}
+
+	for _, block := range splitBlocks(input) {
+		lang, open, source, close, ok := diagramBlock(block)
+		if !ok {
+			text.WriteString(block)
+			continue
+		}
+
+		err := flush()
+		if err != nil {
+			return "", err
+		}
+		source, err = translateDiagram(ctx, l, lang, source, targetLanguage)
+		if err != nil {
+			return "", err
+		}
+		translated.WriteString(open + source + close)
+	}
+
+	err := flush()
+	if err != nil {
+		return "", err
+	}
+	return translated.String(), nil
+}
+
+func translateText(ctx context.Context, l llm.Model, input, targetLanguage string) (string, error) {
 	chunks := chunkMarkdown(input)
 	log.Debug().Msgf("chunked input into %d chunks", len(chunks))
 	translatedChunks := make([]string, len(chunks))
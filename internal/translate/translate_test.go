@@ -194,3 +194,20 @@ Final paragraph to conclude the headers and tables test.`,
 		})
 	}
 }
+
+func TestLastSentenceEnd(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  int
+	}{
+		{"Hello. World", 5},
+		{"안녕하세요. 세계", 5},
+		{"שלום עולם. מה שלומך", 9},
+		{"هل أنت بخير؟ نعم", 11},
+		{"no end", -1},
+	} {
+		if got := lastSentenceEnd([]rune(tc.input)); got != tc.want {
+			t.Errorf("lastSentenceEnd(%q) = %d, want %d", tc.input, got, tc.want)
+		}
+	}
+}
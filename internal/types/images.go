@@ -0,0 +1,13 @@
+package types
+
+import "time"
+
+// ImageInfo is the cached metadata of an image referenced by a document.
+type ImageInfo struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Hash is the BLAKE3 hash of the image content.
+	Hash string `json:"hash"`
+	// CheckedAt is the date and time when the image was last read.
+	CheckedAt time.Time `json:"checked_at"`
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var ErrInvalidLanguage = errors.New("invalid language registry")
+
 type Lang = string
 
 const (
@@ -28,81 +36,198 @@ const (
 	LangBulgarian  Lang = "bg"
 )
 
-var SupportedLanguages = []Lang{
-	LangEnglish,
-	LangSpanish,
-	LangChinese,
-	LangKorean,
-	LangJapanese,
-	LangGerman,
-	LangRussian,
-	LangFrench,
-	LangDutch,
-	LangItalian,
-	LangIndonesian,
-	LangPortuguese,
-	LangSwedish,
-	LangCzech,
-	LangSlovak,
-	LangPolish,
-	LangRomanian,
-	LangHungarian,
-	LangFinnish,
-	LangTurkish,
-	LangDanish,
-	LangNorwegian,
-	LangBulgarian,
+// Direction is the writing direction of a script, as used by the dir attribute of HTML.
+type Direction string
+
+const (
+	LTR Direction = "ltr"
+	RTL Direction = "rtl"
+)
+
+// Language describes a language the site is published in.
+type Language struct {
+	Code Lang
+	// Name is the English name of the language, used in prompts and feeds.
+	Name string
+	// Locale is the OpenGraph locale (language_TERRITORY) of the language.
+	Locale string
+	// Script is the ISO 15924 code of the script the language is written in.
+	Script string
+	// Lingua is the ISO 639-1 code lingua detects the language as, which differs
+	// from Code when lingua knows the language under another name.
+	Lingua string
+	// Aliases are the other language codes browsers send for the language, e.g. nb
+	// for Norwegian Bokmål.
+	Aliases []string
+}
+
+// Registry lists the languages of the site, in the order they are listed. English
+// comes first, as the default language.
+type Registry []Language
+
+// Languages are the languages of the site. Adding a language only takes a line here,
+// a translation run and, optionally, a message catalog. Everything else that knows
+// languages, from language detection to the language map of main.js, is derived from it.
+var Languages = Registry{
+	{Code: LangEnglish, Name: "English", Locale: "en_US", Script: "Latn", Lingua: "en"},
+	{Code: LangSpanish, Name: "Spanish", Locale: "es_ES", Script: "Latn", Lingua: "es"},
+	{Code: LangChinese, Name: "Chinese", Locale: "zh_CN", Script: "Hans", Lingua: "zh"},
+	{Code: LangKorean, Name: "Korean", Locale: "ko_KR", Script: "Kore", Lingua: "ko"},
+	{Code: LangJapanese, Name: "Japanese", Locale: "ja_JP", Script: "Jpan", Lingua: "ja"},
+	{Code: LangGerman, Name: "German", Locale: "de_DE", Script: "Latn", Lingua: "de"},
+	{Code: LangRussian, Name: "Russian", Locale: "ru_RU", Script: "Cyrl", Lingua: "ru"},
+	{Code: LangFrench, Name: "French", Locale: "fr_FR", Script: "Latn", Lingua: "fr"},
+	{Code: LangDutch, Name: "Dutch", Locale: "nl_NL", Script: "Latn", Lingua: "nl"},
+	{Code: LangItalian, Name: "Italian", Locale: "it_IT", Script: "Latn", Lingua: "it"},
+	{Code: LangIndonesian, Name: "Indonesian", Locale: "id_ID", Script: "Latn", Lingua: "id"},
+	{Code: LangPortuguese, Name: "Portuguese", Locale: "pt_BR", Script: "Latn", Lingua: "pt"},
+	{Code: LangSwedish, Name: "Swedish", Locale: "sv_SE", Script: "Latn", Lingua: "sv"},
+	{Code: LangCzech, Name: "Czech", Locale: "cs_CZ", Script: "Latn", Lingua: "cs"},
+	{Code: LangSlovak, Name: "Slovak", Locale: "sk_SK", Script: "Latn", Lingua: "sk"},
+	{Code: LangPolish, Name: "Polish", Locale: "pl_PL", Script: "Latn", Lingua: "pl"},
+	{Code: LangRomanian, Name: "Romanian", Locale: "ro_RO", Script: "Latn", Lingua: "ro"},
+	{Code: LangHungarian, Name: "Hungarian", Locale: "hu_HU", Script: "Latn", Lingua: "hu"},
+	{Code: LangFinnish, Name: "Finnish", Locale: "fi_FI", Script: "Latn", Lingua: "fi"},
+	{Code: LangTurkish, Name: "Turkish", Locale: "tr_TR", Script: "Latn", Lingua: "tr"},
+	{Code: LangDanish, Name: "Danish", Locale: "da_DK", Script: "Latn", Lingua: "da"},
+	{Code: LangNorwegian, Name: "Norwegian", Locale: "nb_NO", Script: "Latn", Lingua: "nb", Aliases: []string{"nb", "nn"}},
+	{Code: LangBulgarian, Name: "Bulgarian", Locale: "bg_BG", Script: "Cyrl", Lingua: "bg"},
+}
+
+// SupportedLanguages are the codes of Languages.
+var SupportedLanguages = Languages.Codes()
+
+// rtlScripts are the ISO 15924 codes of the scripts written right to left.
+var rtlScripts = map[string]bool{
+	"Adlm": true,
+	"Arab": true,
+	"Hebr": true,
+	"Nkoo": true,
+	"Rohg": true,
+	"Syrc": true,
+	"Thaa": true,
+}
+
+// Dir returns the writing direction of the language.
+func (l Language) Dir() Direction {
+	return ScriptDirection(l.Script)
+}
+
+// ScriptDirection returns the writing direction of the ISO 15924 script.
+func ScriptDirection(script string) Direction {
+	if rtlScripts[script] {
+		return RTL
+	}
+	return LTR
+}
+
+// Codes returns the codes of the languages.
+func (r Registry) Codes() []Lang {
+	codes := make([]Lang, len(r))
+	for i, l := range r {
+		codes[i] = l.Code
+	}
+	return codes
+}
+
+// Tags returns the primary subtags of the BCP 47 language tags browsers send for the
+// language: its code, followed by its aliases.
+func (l Language) Tags() []string {
+	return append([]string{l.Code}, l.Aliases...)
+}
+
+// Subtags maps the primary subtag of every language tag the site serves, e.g. nb for
+// nb-NO, to the code of its language.
+func (r Registry) Subtags() map[string]Lang {
+	m := make(map[string]Lang)
+	for _, l := range r {
+		for _, tag := range l.Tags() {
+			m[tag] = l.Code
+		}
+	}
+	return m
+}
+
+// Lookup returns the language of code.
+func (r Registry) Lookup(code Lang) (Language, bool) {
+	for _, l := range r {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+var (
+	codePattern   = regexp.MustCompile(`^[a-z]{2,3}$`)
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}_[A-Z]{2}$`)
+	scriptPattern = regexp.MustCompile(`^[A-Z][a-z]{3}$`)
+)
+
+// Validate reports languages that are listed twice or miss a field, and codes,
+// locales and scripts that are not well formed.
+func (r Registry) Validate() error {
+	if len(r) == 0 || r[0].Code != LangEnglish {
+		return fmt.Errorf("%w: English must come first", ErrInvalidLanguage)
+	}
+	codes := make(map[Lang]bool)
+	names := make(map[string]bool)
+	lingua := make(map[string]bool)
+	aliases := make(map[string]bool)
+	for _, l := range r {
+		for _, alias := range l.Aliases {
+			if !codePattern.MatchString(alias) || aliases[alias] || codes[alias] {
+				return fmt.Errorf("%w: %s: alias %q is malformed or taken", ErrInvalidLanguage, l.Code, alias)
+			}
+			aliases[alias] = true
+		}
+		switch {
+		case !codePattern.MatchString(l.Code):
+			return fmt.Errorf("%w: code %q is not an ISO 639 code", ErrInvalidLanguage, l.Code)
+		case codes[l.Code]:
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidLanguage, l.Code)
+		case l.Name == "" || names[l.Name]:
+			return fmt.Errorf("%w: %s: name %q is empty or taken", ErrInvalidLanguage, l.Code, l.Name)
+		case !localePattern.MatchString(l.Locale):
+			return fmt.Errorf("%w: %s: locale %q is not language_TERRITORY", ErrInvalidLanguage, l.Code, l.Locale)
+		case !scriptPattern.MatchString(l.Script):
+			return fmt.Errorf("%w: %s: script %q is not an ISO 15924 code", ErrInvalidLanguage, l.Code, l.Script)
+		case !codePattern.MatchString(l.Lingua) || lingua[l.Lingua]:
+			return fmt.Errorf("%w: %s: lingua code %q is malformed or taken", ErrInvalidLanguage, l.Code, l.Lingua)
+		}
+		if aliases[l.Code] {
+			return fmt.Errorf("%w: %s is the alias of another language", ErrInvalidLanguage, l.Code)
+		}
+		codes[l.Code] = true
+		names[l.Name] = true
+		lingua[l.Lingua] = true
+	}
+	return nil
 }
 
 func FullLangName(lang Lang) string {
-	switch lang {
-	case LangEnglish:
-		return "English"
-	case LangSpanish:
-		return "Spanish"
-	case LangChinese:
-		return "Chinese"
-	case LangKorean:
-		return "Korean"
-	case LangJapanese:
-		return "Japanese"
-	case LangGerman:
-		return "German"
-	case LangRussian:
-		return "Russian"
-	case LangFrench:
-		return "French"
-	case LangDutch:
-		return "Dutch"
-	case LangItalian:
-		return "Italian"
-	case LangIndonesian:
-		return "Indonesian"
-	case LangPortuguese:
-		return "Portuguese"
-	case LangSwedish:
-		return "Swedish"
-	case LangCzech:
-		return "Czech"
-	case LangSlovak:
-		return "Slovak"
-	case LangPolish:
-		return "Polish"
-	case LangRomanian:
-		return "Romanian"
-	case LangHungarian:
-		return "Hungarian"
-	case LangFinnish:
-		return "Finnish"
-	case LangTurkish:
-		return "Turkish"
-	case LangDanish:
-		return "Danish"
-	case LangNorwegian:
-		return "Norwegian"
-	case LangBulgarian:
-		return "Bulgarian"
-	default:
+	l, ok := Languages.Lookup(lang)
+	if !ok {
 		return "Unknown"
 	}
+	return l.Name
+}
+
+// Locale returns the OpenGraph locale (language_TERRITORY) of the language.
+func Locale(lang Lang) string {
+	l, ok := Languages.Lookup(lang)
+	if !ok {
+		return "en_US"
+	}
+	return l.Locale
+}
+
+// Dir returns the writing direction of the language, left to right for unknown
+// languages.
+func Dir(lang Lang) Direction {
+	l, ok := Languages.Lookup(lang)
+	if !ok {
+		return LTR
+	}
+	return l.Dir()
 }
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLanguages(t *testing.T) {
+	if err := Languages.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(SupportedLanguages) != len(Languages) || SupportedLanguages[0] != LangEnglish {
+		t.Errorf("SupportedLanguages = %v", SupportedLanguages)
+	}
+	if FullLangName(LangNorwegian) != "Norwegian" || Locale(LangNorwegian) != "nb_NO" {
+		t.Error("Norwegian is not looked up in Languages")
+	}
+	subtags := Languages.Subtags()
+	for tag, want := range map[string]Lang{"nb": LangNorwegian, "nn": LangNorwegian, "no": LangNorwegian, "ko": LangKorean, "en": LangEnglish} {
+		if subtags[tag] != want {
+			t.Errorf("Subtags()[%q] = %q, want %s", tag, subtags[tag], want)
+		}
+	}
+	if _, ok := subtags["xx"]; ok {
+		t.Error("Subtags has an unknown language")
+	}
+	if Dir(LangKorean) != LTR || Dir("xx") != LTR || ScriptDirection("Arab") != RTL || ScriptDirection("Hebr") != RTL {
+		t.Error("wrong writing directions")
+	}
+}
+
+func TestInvalidRegistry(t *testing.T) {
+	en := Language{Code: "en", Name: "English", Locale: "en_US", Script: "Latn", Lingua: "en"}
+	ar := Language{Code: "ar", Name: "Arabic", Locale: "ar_SA", Script: "Arab", Lingua: "ar"}
+	if err := (Registry{en, ar}).Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+
+	for name, r := range map[string]Registry{
+		"empty":          {},
+		"english second": {ar, en},
+		"listed twice":   {en, ar, ar},
+		"no name":        {en, {Code: "he", Locale: "he_IL", Script: "Hebr", Lingua: "he"}},
+		"locale":         {en, {Code: "he", Name: "Hebrew", Locale: "he-IL", Script: "Hebr", Lingua: "he"}},
+		"script":         {en, {Code: "he", Name: "Hebrew", Locale: "he_IL", Script: "hebrew", Lingua: "he"}},
+		"lingua taken":   {en, {Code: "he", Name: "Hebrew", Locale: "he_IL", Script: "Hebr", Lingua: "en"}},
+		"alias taken":    {en, {Code: "he", Name: "Hebrew", Locale: "he_IL", Script: "Hebr", Lingua: "he", Aliases: []string{"ar"}}, ar},
+	} {
+		if err := r.Validate(); !errors.Is(err, ErrInvalidLanguage) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidLanguage", name, err)
+		}
+	}
+}
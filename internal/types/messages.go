@@ -0,0 +1,8 @@
+package types
+
+// Message is a machine translation of a message of the user interface.
+type Message struct {
+	// Source is the English message it was translated from.
+	Source string `json:"source"`
+	Text   string `json:"text"`
+}
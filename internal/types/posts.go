@@ -2,8 +2,10 @@ package types
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zeebo/blake3"
@@ -61,6 +63,32 @@ type Document struct {
 	HTML string `json:"html,omitempty" yaml:"html,omitempty"`
 	// Metadata contains any additional metadata parsed from the Markdown document.
 	Metadata Metadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	// TOC lists the headings of the document in order.
+	TOC []*TOCEntry `json:"toc,omitempty" yaml:"toc,omitempty"`
+	// Stats counts the prose of the document.
+	Stats TextStats `json:"stats,omitempty" yaml:"stats,omitempty"`
+	// Renderer is the version of the renderer that produced HTML, TOC and Stats.
+	Renderer int `json:"renderer,omitempty" yaml:"renderer,omitempty"`
+}
+
+// TextStats holds the size of the prose of a document, excluding code blocks.
+type TextStats struct {
+	// Words is the number of words. Ideographs and kana, written without spaces, count as one word each.
+	Words int `json:"words" yaml:"words"`
+	// Characters is the number of characters other than whitespace.
+	Characters int `json:"characters" yaml:"characters"`
+	// ReadingMinutes is the estimated reading time in minutes, at least 1.
+	ReadingMinutes int `json:"reading_minutes" yaml:"reading_minutes"`
+}
+
+// TOCEntry is a heading of a document.
+type TOCEntry struct {
+	// ID is the anchor of the heading, shared by all translations of the document.
+	ID string `json:"id" yaml:"id"`
+	// Level is the heading level, from 1 to 6.
+	Level int `json:"level" yaml:"level"`
+	// Title is the plain text of the heading.
+	Title string `json:"title" yaml:"title"`
 }
 
 // Metadata is a struct that holds various types of meta data parsed from a Markdown document
@@ -69,6 +97,8 @@ type Metadata struct {
 	ID string `json:"id" yaml:"id"`
 	// Author is the author of the document.
 	Author string `json:"author,omitempty" yaml:"author,omitempty"`
+	// Authors lists further authors of the document, in byline order after Author.
+	Authors []string `json:"authors,omitempty" yaml:"authors,omitempty"`
 	// Title is the title of the document.
 	Title string `json:"title,omitempty" yaml:"title,omitempty"`
 	// Description is a brief description of the document.
@@ -83,38 +113,103 @@ type Metadata struct {
 	GoPackage string `json:"go_package,omitempty" yaml:"go_package,omitempty"`
 	// GoRepoURL is the URL of the Go package repository (optional). Only effective if the post is Main Document.
 	GoRepoURL string `json:"go_repourl,omitempty" yaml:"go_repourl,omitempty"`
+	// GoSubPackages lists the import paths of packages below GoPackage, relative to it (optional). Only effective if the post is Main Document.
+	GoSubPackages []string `json:"go_subpackages,omitempty" yaml:"go_subpackages,omitempty"`
 	// Canonical is the canonical URL for the post.
 	Canonical string `json:"canonical,omitempty" yaml:"canonical,omitempty"`
 	// Hidden indicates whether the post should be listed on the front page.
 	Hidden bool `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	// Draft indicates whether the post is unfinished. Drafts are translated but not published.
+	Draft bool `json:"draft,omitempty" yaml:"draft,omitempty"`
+	// PublishAt is the time before which the post is not published (optional).
+	PublishAt time.Time `json:"publish_at,omitempty" yaml:"publish_at,omitempty"`
 	// NoTranslate indicates whether the post should be translated.
 	NoTranslate bool `json:"no_translate,omitempty" yaml:"no_translate,omitempty"`
 	// IgnoreLangs is a list of languages to ignore when translating the post.
 	IgnoreLangs []string `json:"ignore_langs,omitempty" yaml:"ignore_langs,omitempty"`
 	// LangCanonical is the canonical URL for the post in a specific language.
 	LangCanonical map[string]string `json:"lang_canonical,omitempty" yaml:"lang_canonical,omitempty"`
+	// Tags is a list of topics of the post, published as feed categories.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// OGTemplate is the name of the Open Graph image template of the post (optional). Only effective if the post is Main Document.
+	OGTemplate string `json:"og_template,omitempty" yaml:"og_template,omitempty"`
+	// Series is the name of the series the post is part of (optional). Only effective if the post is Main Document.
+	Series string `json:"series,omitempty" yaml:"series,omitempty"`
+	// SeriesOrder is the position of the post in its series. Parts without it are ordered by Date.
+	SeriesOrder int `json:"series_order,omitempty" yaml:"series_order,omitempty"`
+}
+
+// AuthorNames returns Author followed by Authors, without blanks and duplicates.
+func (g *Metadata) AuthorNames() []string {
+	names := make([]string, 0, len(g.Authors)+1)
+	seen := make(map[string]struct{}, len(g.Authors)+1)
+	for _, name := range append([]string{g.Author}, g.Authors...) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Published reports whether the document is neither a draft nor scheduled after now.
+func (g *Metadata) Published(now time.Time) bool {
+	return !g.Draft && !g.PublishAt.After(now)
 }
 
+// Hash returns the hash of the metadata that affects translations. Every field is
+// written with its length, and every list with its number of elements, so that
+// moving text between fields or elements changes the hash.
 func (g *Metadata) Hash() string {
 	h := blake3.New()
-	h.Write([]byte(g.ID))
-	h.WriteString(g.Title)
-	h.WriteString(g.Author)
-	h.WriteString(g.Description)
-	h.WriteString(g.Date.Format(time.RFC3339))
-	h.WriteString(g.Path)
-	h.WriteString(g.GoPackage)
-	h.WriteString(g.GoRepoURL)
-	h.WriteString(g.Canonical)
-	h.WriteString(strconv.FormatBool(g.Hidden))
+	hashField(h, g.ID)
+	hashField(h, g.Title)
+	hashField(h, g.Author)
+	hashList(h, g.Authors)
+	hashField(h, g.Description)
+	hashField(h, g.Date.Format(time.RFC3339))
+	hashField(h, g.Path)
+	hashField(h, g.GoPackage)
+	hashField(h, g.GoRepoURL)
+	hashList(h, g.GoSubPackages)
+	hashField(h, g.Canonical)
+	hashField(h, strconv.FormatBool(g.Hidden))
+	hashList(h, g.Tags)
+	if g.Series != "" {
+		hashField(h, g.Series)
+		hashField(h, strconv.Itoa(g.SeriesOrder))
+	}
+	if g.Draft {
+		hashField(h, strconv.FormatBool(g.Draft))
+	}
+	if !g.PublishAt.IsZero() {
+		hashField(h, g.PublishAt.Format(time.RFC3339))
+	}
 	return hex.EncodeToString(h.Sum(nil))
 }
 
 func (g *Document) Hash() string {
 	h := blake3.New()
-	h.WriteString(g.Type.String())
-	h.WriteString(g.Markdown)
-	h.WriteString(g.HTML)
-	h.WriteString(g.Metadata.Hash())
+	hashField(h, g.Type.String())
+	hashField(h, g.Markdown)
+	hashField(h, g.HTML)
+	hashField(h, g.Metadata.Hash())
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+func hashField(h *blake3.Hasher, s string) {
+	h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	h.WriteString(s)
+}
+
+func hashList(h *blake3.Hasher, list []string) {
+	h.Write(binary.AppendUvarint(nil, uint64(len(list))))
+	for _, s := range list {
+		hashField(h, s)
+	}
+}
@@ -0,0 +1,20 @@
+package types
+
+import "testing"
+
+func TestMetadataHash(t *testing.T) {
+	base := Metadata{ID: "post", Author: "lemonmint", Authors: []string{"ab", "c"}, Tags: []string{"go"}}
+	seen := map[string]string{base.Hash(): "base"}
+	for name, m := range map[string]Metadata{
+		"authors joined":   {ID: "post", Author: "lemonmint", Authors: []string{"abc"}, Tags: []string{"go"}},
+		"authors split":    {ID: "post", Author: "lemonmint", Authors: []string{"a", "bc"}, Tags: []string{"go"}},
+		"author to tags":   {ID: "post", Author: "lemonmint", Authors: []string{"ab"}, Tags: []string{"c", "go"}},
+		"author to author": {ID: "post", Author: "lemonmintab", Authors: []string{"c"}, Tags: []string{"go"}},
+	} {
+		h := m.Hash()
+		if prev, ok := seen[h]; ok {
+			t.Errorf("%s has the hash of %s", name, prev)
+		}
+		seen[h] = name
+	}
+}
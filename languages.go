@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/types"
+)
+
+// languageScript is the module main.js imports the language map from.
+const languageScript = "languages.js"
+
+// webManifest is the web app manifest of the site in one language.
+type webManifest struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	ShortName       string          `json:"short_name"`
+	Description     string          `json:"description"`
+	Lang            types.Lang      `json:"lang"`
+	Dir             types.Direction `json:"dir"`
+	StartURL        string          `json:"start_url"`
+	Scope           string          `json:"scope"`
+	Icons           []manifestIcon  `json:"icons"`
+	ThemeColor      string          `json:"theme_color"`
+	BackgroundColor string          `json:"background_color"`
+	Display         string          `json:"display"`
+}
+
+type manifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// generateLanguageFiles writes the files derived from the language registry: the
+// language map of main.js, and the web app manifest of each language.
+func generateLanguageFiles() error {
+	log.Debug().Msg("start generating language files")
+
+	script, err := encodeLanguageScript(types.Languages)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(cfg.DistDir, languageScript), script, 0644)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range types.Languages {
+		manifest, err := json.Marshal(newWebManifest(l))
+		if err != nil {
+			return err
+		}
+
+		dirs := []string{filepath.Join(cfg.DistDir, l.Code)}
+		if l.Code == types.LangEnglish {
+			dirs = append(dirs, cfg.DistDir)
+		}
+		for _, dir := range dirs {
+			err = os.MkdirAll(dir, 0755)
+			if err != nil {
+				return err
+			}
+			err = os.WriteFile(filepath.Join(dir, "site.webmanifest"), manifest, 0644)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Debug().Msg("done generating language files")
+	return nil
+}
+
+// encodeLanguageScript encodes the English names of the languages, keyed by code, the
+// languages of the primary subtags of language tags, and the default language as a
+// JavaScript module.
+func encodeLanguageScript(languages types.Registry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Generated from the language registry in internal/types/lang.go. Do not edit.\n")
+	b.WriteString("export const languageMap = {\n")
+	for _, l := range languages {
+		code, err := json.Marshal(l.Code)
+		if err != nil {
+			return nil, err
+		}
+		name, err := json.Marshal(l.Name)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("  " + string(code) + ": " + string(name) + ",\n")
+	}
+	b.WriteString("};\n")
+
+	// Sorted, so that the script is the same on every build.
+	subtags := languages.Subtags()
+	b.WriteString("export const languageSubtags = {\n")
+	for _, tag := range slices.Sorted(maps.Keys(subtags)) {
+		name, err := json.Marshal(tag)
+		if err != nil {
+			return nil, err
+		}
+		code, err := json.Marshal(subtags[tag])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("  " + string(name) + ": " + string(code) + ",\n")
+	}
+	b.WriteString("};\n")
+
+	b.WriteString("export const defaultLanguage = \"" + types.LangEnglish + "\";\n")
+	return b.Bytes(), nil
+}
+
+// newWebManifest returns the web app manifest of the site in the language. Every
+// language starts at its home page, as the same app.
+func newWebManifest(l types.Language) *webManifest {
+	startURL := "/"
+	if l.Code != types.LangEnglish {
+		startURL = "/" + l.Code + "/"
+	}
+	return &webManifest{
+		ID:          "/",
+		Name:        cfg.Site.Name,
+		ShortName:   cfg.Site.Name,
+		Description: cfg.Site.Localized(l.Code).Description,
+		Lang:        l.Code,
+		Dir:         l.Dir(),
+		StartURL:    startURL,
+		Scope:       "/",
+		Icons: []manifestIcon{
+			{Src: "/assets/android-chrome-192x192.png", Sizes: "192x192", Type: "image/png"},
+			{Src: "/assets/android-chrome-512x512.png", Sizes: "512x512", Type: "image/png"},
+		},
+		ThemeColor:      "#ffffff",
+		BackgroundColor: "#ffffff",
+		Display:         "standalone",
+	}
+}
@@ -2,12 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 
-	"github.com/lemon-mint/coord"
 	"github.com/lemon-mint/coord/llm"
-	"github.com/lemon-mint/coord/pconf"
-	"github.com/lemon-mint/coord/provider"
 	_ "github.com/lemon-mint/coord/provider/aistudio"
 	_ "github.com/lemon-mint/coord/provider/anthropic"
 	_ "github.com/lemon-mint/coord/provider/openai"
@@ -15,86 +13,97 @@ import (
 	"github.com/pemistahl/lingua-go"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/time/rate"
+	"gosuda.org/website/internal/config"
+	"gosuda.org/website/internal/llmrouter"
+	"gosuda.org/website/internal/types"
 )
 
-var llmClient provider.LLMClient
-var llmModel llm.Model
+var llmRoutes *llmrouter.Routes
 var languageDetector lingua.LanguageDetector
 
-func init() {
-	languages := []lingua.Language{
-		lingua.English,
-		lingua.Spanish,
-		lingua.Chinese,
-		lingua.Korean,
-		lingua.Japanese,
-		lingua.German,
-		lingua.Russian,
-		lingua.French,
-		lingua.Dutch,
-		lingua.Italian,
-		lingua.Indonesian,
-		lingua.Portuguese,
-		lingua.Swedish,
-		lingua.Czech,
+// detectedLanguages maps the languages lingua detects to the languages of the site.
+var detectedLanguages = make(map[lingua.Language]types.Lang)
+
+// initLanguageDetector builds the language detector of the languages of the site. It
+// fails on languages lingua does not know.
+func initLanguageDetector() error {
+	var languages []lingua.Language
+	for _, l := range types.Languages {
+		language := lingua.GetLanguageFromIsoCode639_1(lingua.GetIsoCode639_1FromValue(l.Lingua))
+		if language == lingua.Unknown {
+			return fmt.Errorf("%w: %s: lingua does not detect %q", types.ErrInvalidLanguage, l.Code, l.Lingua)
+		}
+		languages = append(languages, language)
+		detectedLanguages[language] = l.Code
 	}
 
 	languageDetector = lingua.NewLanguageDetectorBuilder().
 		FromLanguages(languages...).
 		Build()
+	return nil
+}
 
+// llmTasks are the tasks routed to the LLM.
+var llmTasks = []string{config.TaskTitle, config.TaskTranslation, config.TaskEvaluation, config.TaskDescription}
+
+// initLLM opens the backends and the routes of the tasks, unless LLM_INIT is false or
+// no backend has credentials, in which case the site is built without translations.
+func initLLM(c config.LLM) error {
 	if os.Getenv("LLM_INIT") == "false" || os.Getenv("LLM_INIT") == "0" {
 		log.Info().Msg("llm init skipped")
-		return
+		return nil
+	}
+	if !llmConfigured(c) {
+		log.Warn().Msg("no llm backend has credentials, llm init skipped")
+		return nil
 	}
 
-	var err error
-	var client provider.LLMClient
+	log.Debug().Msg("initializing llm routes")
+	routes, err := llmrouter.Open(context.Background(), c, llmTasks...)
+	if err != nil {
+		return err
+	}
+	for _, task := range llmTasks {
+		log.Debug().Str("task", task).Str("route", routes.Model(task).Name()).Msg("llm route initialized")
+	}
+	llmRoutes = routes
+	return nil
+}
 
-	if os.Getenv("PROVIDER") == "aistudio" {
-		log.Debug().Msg("initializing llm client")
-		client, err = coord.NewLLMClient(
-			context.Background(),
-			"aistudio",
-			pconf.WithAPIKey(os.Getenv("AI_STUDIO_API_KEY")),
-		)
-		if err != nil {
-			log.Fatal().Err(err).Msg("failed to create llm client")
-		}
-	} else {
-		log.Debug().Str("location", os.Getenv("LOCATION")).Str("project_id", os.Getenv("PROJECT_ID")).Msg("initializing llm client")
-		client, err = coord.NewLLMClient(
-			context.Background(),
-			"vertexai",
-			pconf.WithLocation(os.Getenv("LOCATION")),
-			pconf.WithProjectID(os.Getenv("PROJECT_ID")),
-		)
-		if err != nil {
-			log.Fatal().Err(err).Msg("failed to create llm client")
+// llmConfigured reports whether any backend has credentials.
+func llmConfigured(c config.LLM) bool {
+	for _, b := range c.Backends {
+		if b.Configured() {
+			return true
 		}
 	}
-	llmClient = client
-	log.Debug().Msg("llm client initialized")
-
-	llmModel, err = llmClient.NewLLM("gemini-3.1-flash-lite", &llm.Config{
-		Temperature:           Ptr(float32(0.7)),
-		MaxOutputTokens:       Ptr(65535),
-		SafetyFilterThreshold: llm.BlockOff,
-		ThinkingConfig: &llm.ThinkingConfig{
-			ThinkingLevel: Ptr(llm.ThinkingLevelMinimal),
-		},
-	})
+	return false
+}
 
-	if err != nil {
-		log.Fatal().Err(err).Msg("failed to create llm model")
+// llmModel returns the model of the task, or nil if the LLM is not initialized.
+func llmModel(task string) llm.Model {
+	if llmRoutes == nil {
+		return nil
 	}
-
-	//llmModel = newRateLimitModel(llmModel, rate.Every(time.Minute/9))
-	log.Debug().Msg("llm model initialized")
+	return llmRoutes.Model(task)
 }
 
-func Ptr[T any](t T) *T {
-	return &t
+// closeLLM logs the calls made to each backend and closes them. Later calls do nothing.
+func closeLLM() {
+	if llmRoutes == nil {
+		return
+	}
+	defer func() {
+		llmRoutes = nil
+	}()
+	for _, b := range llmRoutes.Backends() {
+		stats := b.Stats()
+		log.Info().Str("backend", b.Name()).Int("calls", stats.Calls).Int("failures", stats.Failures).Msg("llm backend stats")
+	}
+	err := llmRoutes.Close()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to close llm backends")
+	}
 }
 
 type rateLimitModel struct {
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/types"
+)
+
+// llmsPost is a single post entry of the posts.json content API.
+type llmsPost struct {
+	ID           string                `json:"id"`
+	Title        string                `json:"title"`
+	Description  string                `json:"description,omitempty"`
+	Authors      []string              `json:"authors,omitempty"`
+	Language     types.Lang            `json:"language"`
+	MainLanguage types.Lang            `json:"main_language"`
+	Section      string                `json:"section,omitempty"`
+	Date         time.Time             `json:"date"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	URL          string                `json:"url"`
+	Canonical    string                `json:"canonical"`
+	Alternates   map[types.Lang]string `json:"alternates,omitempty"`
+	GoPackage    string                `json:"go_package,omitempty"`
+	GoRepoURL    string                `json:"go_repourl,omitempty"`
+	Markdown     string                `json:"markdown"`
+}
+
+// llmsIndex is the document served at /<lang>/posts.json. GeneratedAt is the last
+// update of its posts, so that unchanged posts give the same index on every build.
+type llmsIndex struct {
+	Language    types.Lang  `json:"language"`
+	Home        string      `json:"home"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Posts       []*llmsPost `json:"posts"`
+}
+
+// collectLLMsPosts returns the visible posts available in lang, newest first.
+func collectLLMsPosts(gc *GenerationContext, lang types.Lang) []*llmsPost {
+	var posts []*llmsPost
+	for _, post := range sortedPosts(gc.DataStore) {
+		if post.Main.Metadata.Hidden {
+			continue
+		}
+		doc, ok := post.Translated[lang]
+		if !ok {
+			continue
+		}
+
+		body, ok := stripFrontMatter(doc.Markdown)
+		if !ok {
+			log.Warn().Str("path", post.FilePath).Str("lang", lang).Msg("skipping post without front matter in llms export")
+			continue
+		}
+
+		alternates := make(map[types.Lang]string, len(post.Translated))
+		for _, alt := range postLanguages(post) {
+			if alt == lang {
+				continue
+			}
+			alternates[alt] = langURL(alt, post.Path)
+		}
+
+		posts = append(posts, &llmsPost{
+			ID:           post.ID,
+			Title:        doc.Metadata.Title,
+			Description:  doc.Metadata.Description,
+			Authors:      post.Main.Metadata.AuthorNames(),
+			Language:     lang,
+			MainLanguage: post.Main.Metadata.Language,
+			Section:      postSection(post),
+			Date:         doc.Metadata.Date,
+			CreatedAt:    post.CreatedAt.UTC(),
+			UpdatedAt:    post.UpdatedAt.UTC(),
+			URL:          langURL(lang, post.Path),
+			Canonical:    postCanonical(post, lang),
+			Alternates:   alternates,
+			GoPackage:    post.Main.Metadata.GoPackage,
+			GoRepoURL:    post.Main.Metadata.GoRepoURL,
+			Markdown:     strings.TrimSpace(body),
+		})
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Date.After(posts[j].Date)
+	})
+	return posts
+}
+
+// encodeLLMsTxt renders the llms.txt index, grouping posts by section.
+func encodeLLMsTxt(lang types.Lang, posts []*llmsPost) []byte {
+	var b strings.Builder
+	b.WriteString("# " + cfg.Site.Name + "\n\n")
+	b.WriteString("> " + cfg.Site.Localized(lang).About + "\n\n")
+	b.WriteString("This index lists the posts available in " + types.FullLangName(lang) + ".\n")
+	b.WriteString("The full text of every post is available at " + langURL(lang, "/llms-full.txt") + ", ")
+	b.WriteString("and a JSON export with metadata at " + cfg.BaseURL + "/" + lang + "/posts.json.\n")
+
+	var sections []string
+	bySection := make(map[string][]*llmsPost)
+	for _, post := range posts {
+		if _, ok := bySection[post.Section]; !ok {
+			sections = append(sections, post.Section)
+		}
+		bySection[post.Section] = append(bySection[post.Section], post)
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		b.WriteString("\n## " + sectionTitle(section) + "\n\n")
+		for _, post := range bySection[section] {
+			b.WriteString("- [" + post.Title + "](" + post.URL + ")")
+			if post.Description != "" {
+				b.WriteString(": " + post.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n## Optional\n\n")
+	for _, other := range types.SupportedLanguages {
+		if other == lang {
+			continue
+		}
+		b.WriteString("- [" + types.FullLangName(other) + "](" + langURL(other, "/llms.txt") + ")\n")
+	}
+
+	return []byte(b.String())
+}
+
+// encodeLLMsFullTxt renders llms-full.txt, concatenating the markdown of every post.
+func encodeLLMsFullTxt(lang types.Lang, posts []*llmsPost) []byte {
+	var b strings.Builder
+	b.WriteString("# " + cfg.Site.Name + "\n\n")
+	b.WriteString("> " + cfg.Site.Localized(lang).About + "\n")
+
+	for _, post := range posts {
+		b.WriteString("\n---\n\n")
+		b.WriteString("# " + post.Title + "\n\n")
+		b.WriteString("- URL: " + post.Canonical + "\n")
+		if len(post.Authors) > 0 {
+			b.WriteString("- Author: " + strings.Join(post.Authors, ", ") + "\n")
+		}
+		b.WriteString("- Date: " + post.Date.Format("2006-01-02") + "\n")
+		b.WriteString("- Language: " + post.Language + "\n")
+		if post.Description != "" {
+			b.WriteString("- Description: " + post.Description + "\n")
+		}
+		b.WriteString("\n" + post.Markdown + "\n")
+	}
+
+	return []byte(b.String())
+}
+
+// sectionTitle converts a root directory name into a heading.
+func sectionTitle(section string) string {
+	if section == "" {
+		return "Pages"
+	}
+	return strings.ToUpper(section[:1]) + section[1:]
+}
+
+func generateLLMsTxt(gc *GenerationContext, lang types.Lang) error {
+	log.Debug().Str("lang", lang).Msg("start generating llms.txt")
+
+	posts := collectLLMsPosts(gc, lang)
+	updated := cfg.Site.CreatedAt
+	for _, post := range posts {
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+
+	index, err := json.Marshal(&llmsIndex{
+		Language:    lang,
+		Home:        langURL(lang, "/"),
+		GeneratedAt: updated.UTC(),
+		Posts:       posts,
+	})
+	if err != nil {
+		return err
+	}
+
+	llmsTxt := encodeLLMsTxt(lang, posts)
+	llmsFullTxt := encodeLLMsFullTxt(lang, posts)
+
+	dirs := []string{filepath.Join(cfg.DistDir, lang)}
+	if lang == types.LangEnglish {
+		dirs = append(dirs, cfg.DistDir)
+	}
+
+	for _, dir := range dirs {
+		err = os.WriteFile(filepath.Join(dir, "llms.txt"), llmsTxt, 0644)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(dir, "llms-full.txt"), llmsFullTxt, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.WriteFile(filepath.Join(cfg.DistDir, lang, "posts.json"), index, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("lang", lang).Msg("done generating llms.txt")
+	return nil
+}
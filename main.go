@@ -5,11 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"gopkg.eu.org/envloader"
+	"gosuda.org/website/internal/authors"
+	"gosuda.org/website/internal/config"
 	"gosuda.org/website/internal/evaluate"
+	"gosuda.org/website/internal/i18n"
+	"gosuda.org/website/internal/imagemeta"
+	"gosuda.org/website/internal/markdown"
+	"gosuda.org/website/internal/ogimage"
+	"gosuda.org/website/internal/responsive"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
 )
 
 func init() {
@@ -21,16 +31,52 @@ func init() {
 //go:generate go tool templ generate
 //go:generate npm run build
 
-func generate_main() {
-	ds, err := initializeDatabase(dbFile)
+func generate_main(drafts, offline bool) {
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
+	if ds.Images == nil {
+		ds.Images = make(map[string]*types.ImageInfo)
+	}
+	images := imagemeta.New(ds.Images, publicDir, offline)
+	markdown.SetImageCache(images)
+	variants := responsive.New(images, publicDir, cfg.DistDir)
+	markdown.SetImageVariants(variants)
+
+	registry, err := authors.Load(authorsFile)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("failed to load authors file %s", authorsFile)
+	}
+
+	ogTemplates, err := ogimage.LoadTemplates(ogDir)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("failed to load og image templates from %s", ogDir)
+	}
+	err = ogimage.LoadFonts()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load og image fonts")
+	}
+
+	messages, err := i18n.Load(i18nDir)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("failed to load message catalogs from %s", i18nDir)
+	}
+	view.SetCatalog(messages)
+
 	gc := GenerationContext{
 		DataStore: ds,
 		UsedPosts: make(map[string]struct{}),
 		PathMap:   make(map[string]string),
+		Authors:   registry,
+		Images:    images,
+		Variants:  variants,
+
+		OGTemplates: ogTemplates,
+		Avatars:     newAvatarLoader(offline),
+		Messages:    messages,
+		Drafts:      drafts,
 	}
 
 	err = generate(&gc)
@@ -38,18 +84,18 @@ func generate_main() {
 		log.Fatal().Err(err).Msgf("failed to generate website")
 	}
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 
 	log.Info().Msgf("website generated")
 }
 
 func remove_lang_main(postID, lang string) {
-	ds, err := initializeDatabase(dbFile)
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
 	post, ok := ds.Posts[postID]
@@ -61,16 +107,16 @@ func remove_lang_main(postID, lang string) {
 	}
 	delete(post.Translated, lang)
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 }
 
 func get_translation_main(postID, lang string) {
-	ds, err := initializeDatabase(dbFile)
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
 	post, ok := ds.Posts[postID]
@@ -83,15 +129,15 @@ func get_translation_main(postID, lang string) {
 	}
 	fmt.Println(trans.Markdown)
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 }
 func eval_translation_main(postID, lang string) {
-	ds, err := initializeDatabase(dbFile)
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
 	evaluate.DEBUG_MODE = true
@@ -104,22 +150,22 @@ func eval_translation_main(postID, lang string) {
 	if !ok {
 		log.Fatal().Msgf("translation not found for language %s in post %s", lang, postID)
 	}
-	score, err := evaluate.EvaluateTranslation(context.Background(), llmModel, orig.Metadata.Language, trans.Metadata.Language, orig.Markdown, trans.Markdown)
+	score, err := evaluate.EvaluateTranslation(context.Background(), llmModel(config.TaskEvaluation), orig.Metadata.Language, trans.Metadata.Language, orig.Markdown, trans.Markdown)
 	if err != nil {
 		log.Fatal().Err(err).Msgf("failed to evaluate translation")
 	}
 	fmt.Println("score:", score)
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 }
 
 func eval_all_main() {
-	ds, err := initializeDatabase(dbFile)
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
 	for _, post := range ds.Posts {
@@ -130,7 +176,7 @@ func eval_all_main() {
 
 			orig := post.Main
 		retry:
-			score, err := evaluate.EvaluateTranslation(context.Background(), llmModel, orig.Metadata.Language, trans.Metadata.Language, orig.Markdown, trans.Markdown)
+			score, err := evaluate.EvaluateTranslation(context.Background(), llmModel(config.TaskEvaluation), orig.Metadata.Language, trans.Metadata.Language, orig.Markdown, trans.Markdown)
 			if err != nil {
 				log.Error().Err(err).Msgf("failed to evaluate translation")
 				goto retry
@@ -143,24 +189,24 @@ func eval_all_main() {
 		}
 	}
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 }
 
 func edit_db_main() {
-	ds, err := initializeDatabase(dbFile)
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
 	// write to tmp file
-	tmpFile, err := os.Create(dbFile + ".edit")
+	tmpFile, err := os.Create(cfg.DBFile + ".edit")
 	if err != nil {
 		log.Fatal().Err(err).Msgf("failed to create tmp file")
 	}
-	defer os.Remove(dbFile + ".edit")
+	defer os.Remove(cfg.DBFile + ".edit")
 
 	e := json.NewEncoder(tmpFile)
 	e.SetIndent("", "  ")
@@ -177,7 +223,7 @@ func edit_db_main() {
 	log.Info().Msgf("database edit mode enabled, press enter to save and exit")
 	fmt.Scanln()
 
-	f, err := os.Open(dbFile + ".edit")
+	f, err := os.Open(cfg.DBFile + ".edit")
 	if err != nil {
 		log.Fatal().Err(err).Msgf("failed to close edit file")
 	}
@@ -188,17 +234,17 @@ func edit_db_main() {
 		log.Fatal().Err(err).Msgf("failed to decode database")
 	}
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 	log.Info().Msgf("database updated")
 }
 
 func remove_lang_all_main() {
-	ds, err := initializeDatabase(dbFile)
+	ds, err := initializeDatabase(cfg.DBFile)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to initialize database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to initialize database file %s", cfg.DBFile)
 	}
 
 	for _, post := range ds.Posts {
@@ -210,15 +256,17 @@ func remove_lang_all_main() {
 		}
 	}
 
-	err = updateDatabase(dbFile, ds)
+	err = updateDatabase(cfg.DBFile, ds)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("failed to update database file %s", dbFile)
+		log.Fatal().Err(err).Msgf("failed to update database file %s", cfg.DBFile)
 	}
 }
 
 func printUsage() {
 	fmt.Println("Usage:")
 	fmt.Println("  website                         - Generate website")
+	fmt.Println("  website --drafts                - Generate website including drafts and scheduled posts")
+	fmt.Println("  website --offline               - Generate website without downloading external images")
 	fmt.Println("  website remove_lang <postID> <lang>  - Remove language translation from a post")
 	fmt.Println("  website remove_lang_all         - Remove all translations except main language")
 	fmt.Println("  website get_translation <postID> <lang> - Get translation markdown")
@@ -227,25 +275,51 @@ func printUsage() {
 	fmt.Println("  website edit_db                 - Edit database interactively")
 }
 
+// exit logs the stats of the LLM backends and closes them before it exits with code.
+func exit(code int) {
+	closeLLM()
+	os.Exit(code)
+}
+
 func main() {
-	if llmClient != nil {
-		defer llmClient.Close()
+	var err error
+	cfg, err = config.Load(configFile, config.Environ())
+	if err != nil {
+		log.Fatal().Err(err).Msgf("failed to load config file %s", configFile)
+	}
+	err = types.Languages.Validate()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load languages")
+	}
+	err = initLanguageDetector()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load languages")
 	}
-	if llmModel != nil {
-		defer llmModel.Close()
+	err = initLLM(cfg.LLM)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize llm")
+	}
+	defer closeLLM()
+	// log.Fatal skips deferred calls, so it exits through exit as well.
+	zerolog.FatalExitFunc = func() {
+		exit(1)
 	}
 
 	if len(os.Args) == 1 {
-		generate_main()
+		generate_main(false, false)
 		return
 	}
 
 	switch os.Args[1] {
+	case "--drafts", "--offline":
+		// --drafts renders drafts and scheduled posts for preview, --offline never downloads images.
+		generate_main(slices.Contains(os.Args[1:], "--drafts"), slices.Contains(os.Args[1:], "--offline"))
+		return
 	case "remove_lang":
 		if len(os.Args) < 4 {
 			log.Error().Msg("missing arguments: remove_lang <postID> <lang>")
 			printUsage()
-			os.Exit(1)
+			exit(1)
 		}
 		remove_lang_main(os.Args[2], os.Args[3])
 		return
@@ -256,7 +330,7 @@ func main() {
 		if len(os.Args) < 4 {
 			log.Error().Msg("missing arguments: get_translation <postID> <lang>")
 			printUsage()
-			os.Exit(1)
+			exit(1)
 		}
 		get_translation_main(os.Args[2], os.Args[3])
 		return
@@ -264,7 +338,7 @@ func main() {
 		if len(os.Args) < 4 {
 			log.Error().Msg("missing arguments: eval_translation <postID> <lang>")
 			printUsage()
-			os.Exit(1)
+			exit(1)
 		}
 		eval_translation_main(os.Args[2], os.Args[3])
 		return
@@ -277,6 +351,6 @@ func main() {
 	default:
 		log.Error().Msgf("unknown command: %s", os.Args[1])
 		printUsage()
-		os.Exit(1)
+		exit(1)
 	}
 }
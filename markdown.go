@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/pemistahl/lingua-go"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
+	"gosuda.org/website/internal/config"
 	"gosuda.org/website/internal/description"
 	"gosuda.org/website/internal/markdown"
 	"gosuda.org/website/internal/translate"
@@ -44,9 +46,9 @@ func fillMissingMetadata(ctx context.Context, doc *types.Document, path string)
 		doc.Metadata.Path = generatePath(doc.Metadata.Title)
 	}
 
-	if llmModel != nil && doc.Metadata.Description == "" {
+	if llmRoutes != nil && doc.Metadata.Description == "" {
 		log.Debug().Str("path", path).Msgf("generating description for document %s", path)
-		desc, err := description.GenerateDescription(ctx, llmModel, doc.Markdown)
+		desc, err := description.GenerateDescription(ctx, llmModel(config.TaskDescription), doc.Markdown)
 		if err != nil {
 			log.Error().Str("path", path).Err(err).Msgf("failed to generate description for document %s", path)
 		}
@@ -81,9 +83,7 @@ func saveMarkdownWithMetadata(path string, doc *types.Document) error {
 		return err
 	}
 
-	original := doc.Markdown
-	original = strings.TrimPrefix(original, "---\n")
-	_, origDocument, ok := strings.Cut(original, "---\n")
+	origDocument, ok := stripFrontMatter(doc.Markdown)
 	if !ok {
 		return ErrInvalidMarkdown
 	}
@@ -128,8 +128,9 @@ func updatePostAndTranslate(gc *GenerationContext, doc *types.Document, path str
 		post.Translated = make(map[string]*types.Document)
 	}
 	post.Translated[doc.Metadata.Language] = doc
+	rerenderTranslations(post)
 
-	if llmModel != nil {
+	if llmRoutes != nil {
 		if post.Hash != hash {
 			post.Hash = hash
 			post.UpdatedAt = now
@@ -157,6 +158,51 @@ func updatePostAndTranslate(gc *GenerationContext, doc *types.Document, path str
 	return nil
 }
 
+// rerenderTranslations renders translations produced by an older renderer again
+// from their markdown, so that rendering changes apply without translating again.
+func rerenderTranslations(post *types.Post) {
+	ids := markdown.HeadingIDs(post.Main.TOC)
+	for lang, doc := range post.Translated {
+		if doc == post.Main || doc.Renderer >= markdown.RendererVersion {
+			continue
+		}
+
+		log.Debug().Str("path", post.FilePath).Str("lang", lang).Msg("rendering translation again")
+		rendered, err := markdown.ParseMarkdown(doc.Markdown, markdown.WithHeadingIDs(ids))
+		if err != nil {
+			log.Error().Err(err).Str("path", post.FilePath).Str("lang", lang).Msg("failed to render translation")
+			continue
+		}
+		post.Translated[lang] = rendered
+	}
+}
+
+// prepareImages fills the image cache with the images of the markdown files in list and
+// generates their variants, so that rendering does not wait on the network or encoders.
+func prepareImages(gc *GenerationContext, list []string) {
+	log.Debug().Msg("start preparing images")
+
+	var sources []string
+	for _, path := range list {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown":
+		default:
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msgf("failed to read markdown file %s", path)
+			continue
+		}
+		sources = append(sources, markdown.ImageSources(string(data))...)
+	}
+	gc.Images.Prefetch(context.Background(), sources)
+	gc.Variants.Process(sources)
+
+	log.Debug().Int("images", len(sources)).Msg("done preparing images")
+}
+
 func processMarkdownFile(gc *GenerationContext, path string) (*types.Document, error) {
 	log.Debug().Str("path", path).Msgf("start processing markdown file %s", path)
 
@@ -202,11 +248,11 @@ func generatePath(title string) string {
 	langCode := mapDetectedLanguage(lang)
 	log.Debug().Str("title", title).Str("lang", langCode).Msgf("detected language of title %s", title)
 
-	if llmModel != nil && langCode != "en" {
+	if llmRoutes != nil && langCode != "en" {
 		var retries int
 		for retries < 3 {
 			retries++
-			translatedTitle, err := translate.Translate(context.Background(), llmModel, title, types.FullLangName("en"))
+			translatedTitle, err := translate.Translate(context.Background(), llmModel(config.TaskTitle), title, types.FullLangName("en"))
 			if err != nil {
 				log.Error().Err(err).Str("title", title).Msg("failed to translate title")
 				time.Sleep(time.Second * 2)
@@ -219,7 +265,7 @@ func generatePath(title string) string {
 	}
 
 	title = strings.TrimSpace(title)
-	fp := strings.TrimPrefix(title, rootDir)
+	fp := strings.TrimPrefix(title, cfg.RootDir)
 	for strings.HasPrefix(fp, "/") {
 		fp = strings.TrimPrefix(fp, "/")
 	}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/types"
+)
+
+// redirectsHeader marks the start of the generated rules in dist/_redirects.
+const redirectsHeader = `# Language negotiation, generated by the website generator. Do not edit.
+# Unprefixed URLs serve English and are the x-default of every page. Every request
+# to them whose Accept-Language matches a translation is redirected to it, unless the
+# nf_lang cookie set by the language selector names another language, which then
+# replaces the header.
+`
+
+// languageRedirects returns the negotiation rules for path, one per available translation.
+// A rule matches the code of its language and its aliases. Rules are written per page
+// rather than as one splat per language, which would also redirect assets and pages
+// that are not translated into the language.
+func languageRedirects(b *strings.Builder, path string, languages []types.Lang) {
+	for _, lang := range languages {
+		if lang == types.LangEnglish {
+			continue
+		}
+		codes := []string{lang}
+		if l, ok := types.Languages.Lookup(lang); ok {
+			codes = l.Tags()
+		}
+		b.WriteString(path + "  /" + lang + path + "  302!  Language=" + strings.Join(codes, ",") + "\n")
+	}
+}
+
+// generateLanguageRedirects appends the language negotiation rules to dist/_redirects.
+// Only pages served at an unprefixed URL are negotiated, so that explicit language
+// URLs are never redirected.
+func generateLanguageRedirects(gc *GenerationContext) error {
+	log.Debug().Msg("start generating language redirects")
+
+	var b strings.Builder
+	b.WriteString(redirectsHeader)
+
+	b.WriteString("\n")
+	languageRedirects(&b, "/", types.SupportedLanguages)
+
+	for _, post := range sortedPosts(gc.DataStore) {
+		if _, ok := post.Translated[types.LangEnglish]; !ok {
+			continue
+		}
+		b.WriteString("\n")
+		languageRedirects(&b, post.Path, postLanguages(post))
+	}
+
+	err := appendRedirects(b.String())
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Msg("done generating language redirects")
+	return nil
+}
+
+// appendRedirects appends rules to dist/_redirects, after any rules copied from publicDir.
+func appendRedirects(rules string) error {
+	path := filepath.Join(cfg.DistDir, "_redirects")
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var b strings.Builder
+	b.Write(existing)
+	if len(existing) > 0 {
+		if !strings.HasSuffix(string(existing), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(rules)
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
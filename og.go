@@ -0,0 +1,354 @@
+package main
+
+import (
+	_ "image/jpeg"
+
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zeebo/blake3"
+	"gosuda.org/website/internal/ogimage"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
+)
+
+const (
+	// ogPackageTemplate is the Open Graph image template of Go package posts.
+	ogPackageTemplate = "package"
+	// ogCacheDir holds the Open Graph images drawn by previous builds, by their key.
+	// Images no post uses anymore are removed after every build.
+	ogCacheDir = ".cache/og"
+	// avatarCacheDir holds the downloaded avatars of authors across builds.
+	avatarCacheDir = ".cache/avatars"
+	// avatarTimeout bounds the download of a single avatar.
+	avatarTimeout = 10 * time.Second
+	// avatarMaxAge is the age after which cached avatars are downloaded again, so that
+	// changed avatars are drawn.
+	avatarMaxAge = 7 * 24 * time.Hour
+	// maxAvatarSize is the largest avatar downloaded.
+	maxAvatarSize = 4 << 20
+)
+
+// ogTemplate returns the Open Graph image template picked by the post in front matter,
+// which defaults to the package template for Go package posts.
+func ogTemplate(gc *GenerationContext, post *types.Post, lang types.Lang) *ogimage.Template {
+	name := post.Main.Metadata.OGTemplate
+	if _, ok := gc.OGTemplates[ogPackageTemplate]; ok && name == "" && post.Main.Metadata.GoPackage != "" {
+		name = ogPackageTemplate
+	}
+
+	t, err := gc.OGTemplates.Lookup(name)
+	if err != nil {
+		if lang == post.Main.Metadata.Language {
+			log.Warn().Err(err).Str("path", post.FilePath).Msg("using the default og image template")
+		}
+		t, _ = gc.OGTemplates.Lookup(ogimage.DefaultTemplate)
+	}
+	return t
+}
+
+// ogImageJob is an Open Graph image to write to path, as PNG, and next to it as WebP.
+// The avatar at avatar, if any, is loaded by the worker drawing the image.
+type ogImageJob struct {
+	path     string
+	avatar   string
+	template *ogimage.Template
+	post     *ogimage.Post
+}
+
+// newOGImageJob returns the job of the Open Graph image of the post in lang.
+func newOGImageJob(gc *GenerationContext, post *types.Post, lang types.Lang, doc *types.Document) *ogImageJob {
+	t := ogTemplate(gc, post, lang)
+	p := &ogimage.Post{
+		Site:    cfg.Site.Name,
+		Title:   doc.Metadata.Title,
+		Date:    doc.Metadata.Date,
+		Lang:    lang,
+		Tags:    doc.Metadata.Tags,
+		Package: post.Main.Metadata.GoPackage,
+	}
+
+	bylines := postBylines(gc, post, lang)
+	for _, byline := range bylines {
+		p.Authors = append(p.Authors, byline.Name)
+	}
+	if doc.Stats.ReadingMinutes > 0 {
+		p.ReadingTime = view.ReadingTime(lang, doc.Stats.ReadingMinutes)
+	}
+
+	job := &ogImageJob{
+		path:     filepath.Join(cfg.DistDir, "assets", post.ID+"_"+lang+".png"),
+		template: t,
+		post:     p,
+	}
+	if len(bylines) > 0 && t.Has(ogimage.ElementAvatar) {
+		job.avatar = bylines[0].Avatar
+	}
+	return job
+}
+
+// generateOGImages writes the Open Graph image of every post in each of its languages.
+// Images are drawn in parallel, and images drawn by previous builds are reused from
+// ogCacheDir, which keeps only the images of this build afterwards.
+func generateOGImages(gc *GenerationContext) error {
+	log.Debug().Msg("start generating og images")
+	err := os.MkdirAll(filepath.Join(cfg.DistDir, "assets"), 0755)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		used     = make(map[string]bool)
+		drawn    atomic.Int64
+	)
+	queue := make(chan *ogImageJob)
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				key, ok, err := job.write(gc.Avatars)
+				mu.Lock()
+				used[key] = true
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", job.path, err)
+				}
+				mu.Unlock()
+				if ok {
+					drawn.Add(1)
+				}
+			}
+		}()
+	}
+
+	var total int
+	for _, post := range sortedPosts(gc.DataStore) {
+		for _, lang := range types.SupportedLanguages {
+			doc, ok := post.Translated[lang]
+			if !ok {
+				continue
+			}
+			queue <- newOGImageJob(gc, post, lang, doc)
+			total++
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	pruneOGCache(used)
+
+	log.Debug().Int("images", total).Int64("drawn", drawn.Load()).Msg("done generating og images")
+	return nil
+}
+
+// ogImageFormats are the extensions of the Open Graph images and their encoders.
+var ogImageFormats = []struct {
+	ext    string
+	encode func(io.Writer, image.Image) error
+}{
+	{".png", ogimage.Encode},
+	{".webp", ogimage.EncodeWebP},
+}
+
+// write writes the image to its path in each format, drawing it unless it is cached.
+// It returns the cache key of the image and reports whether the image was drawn.
+func (job *ogImageJob) write(avatars *avatarLoader) (string, bool, error) {
+	var avatar string
+	if job.avatar != "" {
+		job.post.Avatar, avatar = avatars.Load(job.avatar)
+	}
+	key := ogimage.Key(job.template, job.post, avatar)
+
+	base := strings.TrimSuffix(job.path, filepath.Ext(job.path))
+	cached := filepath.Join(ogCacheDir, key)
+
+	var images [][]byte
+	for _, f := range ogImageFormats {
+		data, err := os.ReadFile(cached + f.ext)
+		if err != nil {
+			images = nil
+			break
+		}
+		images = append(images, data)
+	}
+
+	drawn := images == nil
+	if drawn {
+		m := ogimage.GenerateImage(job.template, job.post)
+		for _, f := range ogImageFormats {
+			var b bytes.Buffer
+			err := f.encode(&b, m)
+			if err != nil {
+				return key, true, err
+			}
+			images = append(images, b.Bytes())
+		}
+
+		err := os.MkdirAll(ogCacheDir, 0755)
+		for i, f := range ogImageFormats {
+			if err == nil {
+				err = os.WriteFile(cached+f.ext, images[i], 0644)
+			}
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("path", cached).Msg("failed to cache og image")
+		}
+	}
+
+	for i, f := range ogImageFormats {
+		err := os.WriteFile(base+f.ext, images[i], 0644)
+		if err != nil {
+			return key, drawn, err
+		}
+	}
+	return key, drawn, nil
+}
+
+// pruneOGCache removes the images of ogCacheDir whose key is not in used.
+func pruneOGCache(used map[string]bool) {
+	entries, err := os.ReadDir(ogCacheDir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Str("path", ogCacheDir).Msg("failed to prune og image cache")
+		}
+		return
+	}
+
+	var removed int
+	for _, entry := range entries {
+		key := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if used[key] {
+			continue
+		}
+		err = os.Remove(filepath.Join(ogCacheDir, entry.Name()))
+		if err != nil {
+			log.Warn().Err(err).Str("path", entry.Name()).Msg("failed to prune og image cache")
+			continue
+		}
+		removed++
+	}
+	log.Debug().Int("removed", removed).Msg("pruned og image cache")
+}
+
+// avatarLoader loads the avatars drawn on Open Graph images. Local avatars are read
+// from the public directory. External avatars are downloaded and kept in
+// avatarCacheDir, and downloaded again once they are older than avatarMaxAge; in
+// offline mode, cached avatars are used regardless of their age and uncached external
+// avatars are left out.
+type avatarLoader struct {
+	mu      sync.Mutex
+	avatars map[string]*loadedAvatar
+	offline bool
+	client  *http.Client
+}
+
+// loadedAvatar is an avatar loaded once, however many images draw it.
+type loadedAvatar struct {
+	once  sync.Once
+	image image.Image
+	hash  string
+}
+
+func newAvatarLoader(offline bool) *avatarLoader {
+	return &avatarLoader{
+		avatars: make(map[string]*loadedAvatar),
+		offline: offline,
+		client:  &http.Client{Timeout: avatarTimeout},
+	}
+}
+
+// Load returns the avatar at src and the hash of its data, or nil and an empty hash
+// if it cannot be loaded. It is safe for concurrent use; different avatars are
+// loaded in parallel, and callers of the same avatar wait for a single load.
+func (a *avatarLoader) Load(src string) (image.Image, string) {
+	a.mu.Lock()
+	avatar, ok := a.avatars[src]
+	if !ok {
+		avatar = &loadedAvatar{}
+		a.avatars[src] = avatar
+	}
+	a.mu.Unlock()
+
+	avatar.once.Do(func() {
+		data, err := a.load(src)
+		if err == nil && data != nil {
+			avatar.image, _, err = image.Decode(bytes.NewReader(data))
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("src", src).Msg("failed to load avatar")
+		} else if avatar.image != nil {
+			sum := blake3.Sum256(data)
+			avatar.hash = hex.EncodeToString(sum[:16])
+		}
+	})
+	return avatar.image, avatar.hash
+}
+
+// load returns the data of the avatar at src, or nil if it is left out.
+func (a *avatarLoader) load(src string) ([]byte, error) {
+	if strings.HasPrefix(src, "/") {
+		return os.ReadFile(filepath.Join(publicDir, filepath.FromSlash(src)))
+	}
+
+	sum := blake3.Sum256([]byte(src))
+	cached := filepath.Join(avatarCacheDir, hex.EncodeToString(sum[:16]))
+	stale, err := os.ReadFile(cached)
+	if err == nil {
+		info, err := os.Stat(cached)
+		if a.offline || err == nil && time.Since(info.ModTime()) < avatarMaxAge {
+			return stale, nil
+		}
+	}
+	if a.offline {
+		log.Debug().Str("src", src).Msg("avatar is not cached, leaving it out in offline mode")
+		return nil, nil
+	}
+
+	data, err := a.fetch(src)
+	if err != nil {
+		if stale != nil {
+			log.Warn().Err(err).Str("src", src).Msg("failed to refresh avatar, using the cached one")
+			return stale, nil
+		}
+		return nil, err
+	}
+	err = os.MkdirAll(avatarCacheDir, 0755)
+	if err == nil {
+		err = os.WriteFile(cached, data, 0644)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("path", cached).Msg("failed to cache avatar")
+	}
+	return data, nil
+}
+
+func (a *avatarLoader) fetch(src string) ([]byte, error) {
+	resp, err := a.client.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxAvatarSize))
+}
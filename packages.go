@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
+)
+
+// goImportMeta returns the content of the go-import meta tag of a module.
+func goImportMeta(importPath, repoURL string) string {
+	return importPath + " git " + repoURL
+}
+
+// goSourceMeta returns the content of the go-source meta tag of a module,
+// or an empty string if the repository host's URL layout is unknown.
+func goSourceMeta(importPath, repoURL string) string {
+	repo := strings.TrimSuffix(repoURL, ".git")
+	u, err := url.Parse(repo)
+	if err != nil {
+		return ""
+	}
+
+	switch u.Host {
+	case "github.com":
+		return importPath + " " + repo + " " + repo + "/tree/HEAD{/dir} " + repo + "/blob/HEAD{/dir}/{file}#L{line}"
+	case "gitlab.com":
+		return importPath + " " + repo + " " + repo + "/-/tree/HEAD{/dir} " + repo + "/-/blob/HEAD{/dir}/{file}#L{line}"
+	default:
+		return ""
+	}
+}
+
+// vanityPath returns the path of importPath on this site, or false if the
+// import path is not on the vanity host of the site.
+func vanityPath(importPath string) (string, bool) {
+	rest, ok := strings.CutPrefix(importPath, cfg.Site.VanityHost+"/")
+	if !ok || rest == "" {
+		return "", false
+	}
+	return "/" + rest, true
+}
+
+// collectGoPackages returns one post per declared Go module, sorted by import path.
+// When several posts declare the same module, the one served at the module path wins.
+func collectGoPackages(gc *GenerationContext) []*types.Post {
+	byImportPath := make(map[string]*types.Post)
+	for _, post := range sortedPosts(gc.DataStore) {
+		pm := post.Main.Metadata
+		if pm.GoPackage == "" || pm.GoRepoURL == "" {
+			continue
+		}
+
+		if prev, ok := byImportPath[pm.GoPackage]; ok {
+			path, _ := vanityPath(pm.GoPackage)
+			if prev.Path == path || post.Path != path {
+				continue
+			}
+		}
+		byImportPath[pm.GoPackage] = post
+	}
+
+	posts := make([]*types.Post, 0, len(byImportPath))
+	for _, post := range byImportPath {
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Main.Metadata.GoPackage < posts[j].Main.Metadata.GoPackage
+	})
+	return posts
+}
+
+func generatePackagePages(gc *GenerationContext) error {
+	log.Debug().Msg("start generating package pages")
+
+	packages := collectGoPackages(gc)
+
+	err := generateGoImportPages(packages)
+	if err != nil {
+		return err
+	}
+
+	for _, lang := range types.SupportedLanguages {
+		err = generatePackagesIndex(packages, lang)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Debug().Msg("done generating package pages")
+	return nil
+}
+
+// generateGoImportPages writes a go-get page for every declared subpackage of each module,
+// and a fallback page that the edge rewrites every other path below the module to.
+func generateGoImportPages(packages []*types.Post) error {
+	var rules strings.Builder
+	rules.WriteString("# Go vanity import paths, generated by the website generator. Do not edit.\n")
+
+	var b bytes.Buffer
+	ctx := context.Background()
+
+	for _, post := range packages {
+		pm := post.Main.Metadata
+		root, ok := vanityPath(pm.GoPackage)
+		if !ok {
+			log.Warn().Str("path", post.FilePath).Str("go_package", pm.GoPackage).Msg("go package is not served by this site, skipping vanity import pages")
+			continue
+		}
+
+		goImport := goImportMeta(pm.GoPackage, pm.GoRepoURL)
+		goSource := goSourceMeta(pm.GoPackage, pm.GoRepoURL)
+
+		pages := map[string]string{
+			root + "/go-get": pm.GoPackage,
+		}
+		for _, sub := range pm.GoSubPackages {
+			sub = strings.Trim(sub, "/")
+			if sub == "" {
+				continue
+			}
+			pages[root+"/"+sub] = pm.GoPackage + "/" + sub
+		}
+
+		for path, importPath := range pages {
+			b.Reset()
+			err := view.GoImportPage(importPath, goImport, goSource).Render(ctx, &b)
+			if err != nil {
+				return err
+			}
+
+			fp := filepath.Join(cfg.DistDir, path+".html")
+			err = os.MkdirAll(filepath.Dir(fp), 0755)
+			if err != nil {
+				return err
+			}
+
+			err = os.WriteFile(fp, b.Bytes(), 0644)
+			if err != nil {
+				return err
+			}
+		}
+
+		rules.WriteString(root + "/*  " + root + "/go-get  200\n")
+	}
+
+	return appendRedirects(rules.String())
+}
+
+func generatePackagesIndex(packages []*types.Post, lang types.Lang) error {
+	var list []*view.GoPackage
+	for _, post := range packages {
+		pm := post.Main.Metadata
+		doc, ok := post.Translated[lang]
+		if !ok {
+			doc = post.Main
+		}
+
+		list = append(list, &view.GoPackage{
+			ImportPath:  pm.GoPackage,
+			Title:       doc.Metadata.Title,
+			Description: doc.Metadata.Description,
+			URL:         langURL(doc.Metadata.Language, post.Path),
+			RepoURL:     strings.TrimSuffix(pm.GoRepoURL, ".git"),
+			SubPackages: pm.GoSubPackages,
+		})
+	}
+
+	meta := &view.Metadata{
+		Language:    lang,
+		Title:       cfg.Site.Name + " | " + view.Message(lang, "packages"),
+		Description: view.Message(lang, "packages_description", "{site}", cfg.Site.Name),
+		Author:      cfg.Site.Name,
+		Image:       cfg.BaseURL + "/assets/images/ogp_placeholder.png",
+		URL:         langURL(lang, "/packages/"),
+		Canonical:   langURL(lang, "/packages/"),
+		BaseURL:     cfg.BaseURL,
+		CreatedAt:   cfg.Site.CreatedAt,
+		UpdatedAt:   lastUpdate(packages),
+		Type:        "website",
+		SiteName:    cfg.Site.Name,
+		Locale:      types.Locale(lang),
+	}
+
+	alt := &view.Alternate{Default: langURL(types.LangEnglish, "/packages/")}
+	for _, other := range types.SupportedLanguages {
+		alt.Versions = append(alt.Versions, view.KV{Key: other, Value: langURL(other, "/packages/")})
+	}
+	meta.Alternate = alt
+
+	var b bytes.Buffer
+	err := view.PackagesPage(meta, list).Render(context.Background(), &b)
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{filepath.Join(cfg.DistDir, lang, "packages")}
+	if lang == types.LangEnglish {
+		dirs = append(dirs, filepath.Join(cfg.DistDir, "packages"))
+	}
+	for _, dir := range dirs {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(dir, "index.html"), b.Bytes(), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,3 +1,5 @@
+import { languageMap, languageSubtags, defaultLanguage } from './languages.js';
+
 function isCrawler() {
   const userAgent = navigator.userAgent.toLowerCase();
   const crawlerPattern =
@@ -20,51 +22,52 @@ function getAlternateLinks() {
   }, {});
 }
 
-// Language mapping
-const languageMap = {
-  en: 'English',
-  es: 'Spanish',
-  zh: 'Chinese',
-  ko: 'Korean',
-  ja: 'Japanese',
-  de: 'German',
-  ru: 'Russian',
-  fr: 'French',
-  nl: 'Dutch',
-  it: 'Italian',
-  id: 'Indonesian',
-  pt: 'Portuguese',
-  sv: 'Swedish',
-  cs: 'Czech',
-  sk: 'Slovak',
-  pl: 'Polish',
-  ro: 'Romanian',
-  hu: 'Hungarian',
-  fi: 'Finnish',
-  tr: 'Turkish',
-  da: 'Danish',
-  no: 'Norwegian',
-  bg: 'Bulgarian',
-};
+// Returns the site language of a BCP 47 language tag, e.g. "no" for "nb-NO", looked up
+// by its primary subtag in the table generated from the language registry.
+function siteLanguage(tag) {
+  return languageSubtags[tag.toLowerCase().split('-')[0]] || null;
+}
+
+// Returns the language the visitor prefers: the one they chose with the language
+// selector, remembered in the nf_lang cookie like the edge redirects do, or else the
+// first of their browser languages the site is published in.
+function preferredLanguage() {
+  const cookie = document.cookie
+    .split('; ')
+    .find((c) => c.startsWith('nf_lang='));
+  if (cookie) {
+    return siteLanguage(cookie.slice('nf_lang='.length));
+  }
+  for (const tag of navigator.languages || [navigator.language]) {
+    const code = siteLanguage(tag);
+    if (code) return code;
+  }
+  return null;
+}
 
 async function displayAlt() {
   if (isCrawler()) return;
 
-  const supportedLanguages = Object.keys(languageMap);
-
-  const browserLang = navigator.language.slice(0, 2);
-  const pageLang = document.documentElement.lang || 'en';
+  const preferredLang = preferredLanguage();
+  const pageLang = document.documentElement.lang || defaultLanguage;
 
   alternateLinks = getAlternateLinks();
+  // The default language is served at the x-default URL.
+  const defaultLink = document.querySelector(
+    'link[rel="alternate"][hreflang="x-default"]'
+  );
+  if (!alternateLinks[defaultLanguage] && defaultLink) {
+    alternateLinks[defaultLanguage] = defaultLink.href;
+  }
 
-  // Only show selector if browser language is supported and different from page language
+  // Only show the banner if the page is published in the preferred language
   if (
-    browserLang !== pageLang &&
-    supportedLanguages.includes(browserLang) &&
-    alternateLinks[browserLang]
+    preferredLang &&
+    preferredLang !== pageLang &&
+    alternateLinks[preferredLang]
   ) {
-    const targetUrl = alternateLinks[browserLang];
-    const languageName = languageMap[browserLang] || browserLang;
+    const targetUrl = alternateLinks[preferredLang];
+    const languageName = languageMap[preferredLang] || preferredLang;
 
     const selector = document.createElement('div');
     selector.className =
@@ -84,7 +87,7 @@ async function displayAlt() {
     </a>
 
     <button id="language-selector-close" type="button" aria-label="Close Language Selector"
-            class="ml-auto inline-flex items-center p-1 opacity-50
+            class="ms-auto inline-flex items-center p-1 opacity-50
                     hover:opacity-100 focus:outline-none focus:ring-2
                     focus:ring-blue-500 focus:ring-offset-2 dark:focus:ring-offset-0">
         <svg class="w-5 h-5" viewBox="0 0 24 24" fill="none"
@@ -102,6 +105,12 @@ async function displayAlt() {
       document.body.insertBefore(selector, document.body.firstChild);
     }
 
+    const link = selector.querySelector('a');
+    link.hash = window.location.hash;
+    link.addEventListener('click', () => {
+      rememberLanguage(preferredLang);
+    });
+
     document.getElementById('language-selector-close').addEventListener('click', () => {
       selector.remove();
     });
@@ -869,6 +878,13 @@ async function initTelemetry() {
 // Auto-initialize when the script loads
 initTelemetry();
 
+// countText formats a count with the localized label of its placeholder
+// (data-label), remembering the count in data-count.
+function countText(el, fallback, count) {
+  el.dataset.count = String(count);
+  return `${el.dataset.label || fallback} ${count}`;
+}
+
 // Hydrate view and like placeholders inserted server-side.
 async function hydrateCounts() {
   if (isCrawler()) return;
@@ -881,12 +897,12 @@ async function hydrateCounts() {
     try {
       const data = await getViewCount(url);
       if (data && typeof data.count !== 'undefined') {
-        el.textContent = `views ${data.count}`;
+        el.textContent = countText(el, 'views', data.count);
       } else {
-        el.textContent = 'views 0';
+        el.textContent = countText(el, 'views', 0);
       }
     } catch (e) {
-      el.textContent = 'views 0';
+      el.textContent = countText(el, 'views', 0);
     }
   }
 
@@ -899,33 +915,32 @@ async function hydrateCounts() {
     try {
       const data = await getLikeCount(url);
       const count = data && typeof data.count !== 'undefined' ? data.count : 0;
-      if (span) span.textContent = `likes ${count}`;
+      if (span) span.textContent = countText(span, 'likes', count);
     } catch (e) {
-      if (span) span.textContent = 'likes 0';
+      if (span) span.textContent = countText(span, 'likes', 0);
     }
 
     // Attach click handler to record a like and update UI optimistically
     btn.addEventListener('click', async (ev) => {
       ev.preventDefault();
       if (!span) return;
-      const numeric =
-        parseInt((span.textContent || '').replace(/\D/g, ''), 10) || 0;
+      const numeric = parseInt(span.dataset.count || '', 10) || 0;
       // optimistic update
-      span.textContent = `likes ${numeric + 1}`;
+      span.textContent = countText(span, 'likes', numeric + 1);
       try {
         const ok = await recordLike(url);
         if (!ok) {
           // revert on failure
-          span.textContent = `likes ${numeric}`;
+          span.textContent = countText(span, 'likes', numeric);
           return;
         }
         // confirm with server
         const fresh = await getLikeCount(url);
         if (fresh && typeof fresh.count !== 'undefined') {
-          span.textContent = `likes ${fresh.count}`;
+          span.textContent = countText(span, 'likes', fresh.count);
         }
       } catch (e) {
-        span.textContent = `likes ${numeric}`;
+        span.textContent = countText(span, 'likes', numeric);
       }
     });
   }
@@ -959,7 +974,7 @@ async function hydrateSummaryCounts() {
       const entry = map[url];
       const count =
         entry && typeof entry.view_count !== 'undefined' ? entry.view_count : 0;
-      el.textContent = `views ${count}`;
+      el.textContent = countText(el, 'views', count);
     }
     // Update like placeholders
     const likeEls = document.querySelectorAll('[data-summary-like-count]');
@@ -969,15 +984,15 @@ async function hydrateSummaryCounts() {
       const entry = map[url];
       const count =
         entry && typeof entry.like_count !== 'undefined' ? entry.like_count : 0;
-      el.textContent = `likes ${count}`;
+      el.textContent = countText(el, 'likes', count);
     }
   } catch (e) {
     console.error('hydrateSummaryCounts failed:', e);
     for (const el of document.querySelectorAll('[data-summary-view-count]')) {
-      el.textContent = 'views 0';
+      el.textContent = countText(el, 'views', 0);
     }
     for (const el of document.querySelectorAll('[data-summary-like-count]')) {
-      el.textContent = 'likes 0';
+      el.textContent = countText(el, 'likes', 0);
     }
   }
 }
@@ -1127,6 +1142,12 @@ function createDropdownItem(
   return button;
 }
 
+// Persist an explicit language choice. The edge redirects generated from Go
+// use this cookie instead of Accept-Language once it is set.
+function rememberLanguage(languageCode) {
+  document.cookie = `nf_lang=${languageCode}; path=/; max-age=31536000; samesite=lax`;
+}
+
 // Helper function to handle language selection
 function handleLanguageSelection(
   languageCode,
@@ -1138,7 +1159,9 @@ function handleLanguageSelection(
   return function () {
     updateDropdownButtonText(dropdownButton, languageName);
     dropdownContent.classList.remove('show');
-    window.location.href = href;
+    rememberLanguage(languageCode);
+    // Heading IDs are shared by all translations, so keep the section.
+    window.location.href = href + window.location.hash;
   };
 }
 
@@ -1220,3 +1243,68 @@ function initDropdown() {
 document.addEventListener('DOMContentLoaded', function () {
   initDropdown();
 });
+
+// Highlight the table of contents entry of the section being read.
+function initScrollSpy() {
+  const links = Array.from(document.querySelectorAll('[data-toc] a[href^="#"]'));
+  if (links.length === 0 || !('IntersectionObserver' in window)) return;
+
+  const linkById = new Map(
+    links.map((link) => [decodeURIComponent(link.hash.slice(1)), link])
+  );
+  const headings = Array.from(linkById.keys())
+    .map((id) => document.getElementById(id))
+    .filter(Boolean);
+
+  const visible = new Set();
+  const observer = new IntersectionObserver(
+    (entries) => {
+      entries.forEach((entry) => {
+        if (entry.isIntersecting) {
+          visible.add(entry.target);
+        } else {
+          visible.delete(entry.target);
+        }
+      });
+
+      const current = headings.find((heading) => visible.has(heading));
+      if (!current) return;
+      links.forEach((link) => link.removeAttribute('aria-current'));
+      linkById.get(current.id).setAttribute('aria-current', 'true');
+    },
+    { rootMargin: '0px 0px -70% 0px' }
+  );
+  headings.forEach((heading) => observer.observe(heading));
+}
+
+document.addEventListener('DOMContentLoaded', initScrollSpy);
+
+// Switch between the panels of tabbed code blocks.
+function initCodeTabs() {
+  document.querySelectorAll('[data-code-tabs]').forEach((group) => {
+    const tabs = Array.from(group.querySelectorAll('[role="tab"]'));
+    const select = (selected) => {
+      tabs.forEach((tab) => {
+        const active = tab === selected;
+        tab.setAttribute('aria-selected', active ? 'true' : 'false');
+        tab.tabIndex = active ? 0 : -1;
+        const panel = document.getElementById(tab.getAttribute('aria-controls'));
+        if (panel) panel.hidden = !active;
+      });
+    };
+
+    tabs.forEach((tab, i) => {
+      tab.addEventListener('click', () => select(tab));
+      tab.addEventListener('keydown', (event) => {
+        const step = { ArrowRight: 1, ArrowLeft: -1 }[event.key];
+        if (!step) return;
+        const next = tabs[(i + step + tabs.length) % tabs.length];
+        select(next);
+        next.focus();
+      });
+    });
+    select(tabs[0]);
+  });
+}
+
+document.addEventListener('DOMContentLoaded', initCodeTabs);
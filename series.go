@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
+)
+
+// series is a set of posts sharing the same series name, in reading order.
+type series struct {
+	Slug  string
+	Name  string
+	Posts []*types.Post
+}
+
+// seriesSlug returns the URL path segment of a series name.
+func seriesSlug(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, name)
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	return strings.Trim(slug, "-")
+}
+
+// seriesURL returns the URL of the landing page of a series in lang.
+func seriesURL(slug string, lang types.Lang) string {
+	return langURL(lang, "/series/"+slug+"/")
+}
+
+// collectSeries returns every series keyed by slug. Parts are ordered by
+// series_order; parts without it follow, ordered by date.
+func collectSeries(gc *GenerationContext) map[string]*series {
+	all := make(map[string]*series)
+	for _, post := range sortedPosts(gc.DataStore) {
+		name := strings.TrimSpace(post.Main.Metadata.Series)
+		if name == "" {
+			continue
+		}
+		slug := seriesSlug(name)
+		if slug == "" {
+			log.Warn().Str("path", post.FilePath).Str("series", name).Msg("series name has no URL-safe characters, skipping")
+			continue
+		}
+
+		s, ok := all[slug]
+		if !ok {
+			s = &series{Slug: slug, Name: name}
+			all[slug] = s
+		}
+		s.Posts = append(s.Posts, post)
+	}
+
+	for _, s := range all {
+		sort.SliceStable(s.Posts, func(i, j int) bool {
+			a, b := s.Posts[i].Main.Metadata, s.Posts[j].Main.Metadata
+			if a.SeriesOrder != b.SeriesOrder {
+				if a.SeriesOrder == 0 || b.SeriesOrder == 0 {
+					return b.SeriesOrder == 0
+				}
+				return a.SeriesOrder < b.SeriesOrder
+			}
+			return a.Date.Before(b.Date)
+		})
+	}
+	return all
+}
+
+// seriesNav returns the series navigation box of post in lang, or nil if the post is not part of a series.
+func seriesNav(all map[string]*series, post *types.Post, lang types.Lang) *view.SeriesNav {
+	s, ok := all[seriesSlug(post.Main.Metadata.Series)]
+	if !ok {
+		return nil
+	}
+
+	nav := &view.SeriesNav{
+		Name: s.Name,
+		URL:  seriesURL(s.Slug, lang),
+	}
+	for i, part := range s.Posts {
+		if part == post {
+			nav.Current = i
+		}
+		nav.Parts = append(nav.Parts, seriesPart(part, lang))
+	}
+	return nav
+}
+
+// seriesPart links a part of a series in lang, falling back to its main language version.
+func seriesPart(post *types.Post, lang types.Lang) *view.SeriesPart {
+	if doc, ok := post.Translated[lang]; ok {
+		return &view.SeriesPart{Title: doc.Metadata.Title, URL: langURL(lang, post.Path)}
+	}
+	return &view.SeriesPart{
+		Title: post.Main.Metadata.Title,
+		URL:   langURL(post.Main.Metadata.Language, post.Path),
+	}
+}
+
+func generateSeriesPages(gc *GenerationContext) error {
+	log.Debug().Msg("start generating series pages")
+
+	for _, s := range collectSeries(gc) {
+		for _, lang := range types.SupportedLanguages {
+			err := generateSeriesPage(gc, s, lang)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Debug().Msg("done generating series pages")
+	return nil
+}
+
+func generateSeriesPage(gc *GenerationContext, s *series, lang types.Lang) error {
+	var previews []*view.BlogPostPreview
+	for _, post := range s.Posts {
+		preview, ok := postPreview(gc, post, lang)
+		if !ok {
+			preview, _ = postPreview(gc, post, post.Main.Metadata.Language)
+		}
+		previews = append(previews, preview)
+	}
+
+	path := "/series/" + s.Slug + "/"
+	meta := &view.Metadata{
+		Language:    lang,
+		Title:       cfg.Site.Name + " | " + s.Name,
+		Description: view.Message(lang, "series_description", "{series}", s.Name, "{count}", strconv.Itoa(len(s.Posts)), "{site}", cfg.Site.Name),
+		Author:      cfg.Site.Name,
+		Image:       ogImageURL(s.Posts[0], s.Posts[0].Main.Metadata.Language),
+		URL:         langURL(lang, path),
+		Canonical:   langURL(lang, path),
+		BaseURL:     cfg.BaseURL,
+		CreatedAt:   s.Posts[0].CreatedAt,
+		UpdatedAt:   lastUpdate(s.Posts),
+		Type:        "website",
+		SiteName:    cfg.Site.Name,
+		Locale:      types.Locale(lang),
+	}
+
+	alt := &view.Alternate{Default: langURL(types.LangEnglish, path)}
+	for _, other := range types.SupportedLanguages {
+		alt.Versions = append(alt.Versions, view.KV{Key: other, Value: langURL(other, path)})
+	}
+	meta.Alternate = alt
+
+	var b bytes.Buffer
+	err := view.SeriesPage(meta, s.Name, previews).Render(context.Background(), &b)
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{filepath.Join(cfg.DistDir, lang, "series", s.Slug)}
+	if lang == types.LangEnglish {
+		dirs = append(dirs, filepath.Join(cfg.DistDir, "series", s.Slug))
+	}
+	for _, dir := range dirs {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(dir, "index.html"), b.Bytes(), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
+)
+
+// Limits of a single sitemap file defined by the sitemaps.org protocol.
+const (
+	sitemapMaxURLs  = 50000
+	sitemapMaxBytes = 50 * 1024 * 1024
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func generateSitemaps(gc *GenerationContext) error {
+	log.Debug().Msg("start generating sitemaps")
+
+	var index []*view.SitemapURL
+	for _, lang := range types.SupportedLanguages {
+		urls := collectSitemapURLs(gc, lang)
+
+		files, err := encodeSitemaps(urls)
+		if err != nil {
+			return err
+		}
+
+		var lastMod time.Time
+		for _, u := range urls {
+			if u.LastMod.After(lastMod) {
+				lastMod = u.LastMod
+			}
+		}
+
+		for i, data := range files {
+			name := "sitemap.xml"
+			if len(files) > 1 {
+				name = "sitemap-" + strconv.Itoa(i+1) + ".xml"
+			}
+
+			path := filepath.Join(cfg.DistDir, lang, name)
+			if lang == types.LangEnglish {
+				path = filepath.Join(cfg.DistDir, name)
+			}
+
+			err = os.WriteFile(path, data, 0644)
+			if err != nil {
+				return err
+			}
+
+			index = append(index, &view.SitemapURL{
+				Loc:     langURL(lang, "/"+name),
+				LastMod: lastMod,
+			})
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	err := view.SitemapIndex(index).Render(context.Background(), &b)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(cfg.DistDir, "sitemap_index.xml"), b.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = writeRobotsTxt()
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Msg("done generating sitemaps")
+	return nil
+}
+
+// writeRobotsTxt writes dist/robots.txt: the crawler rules copied from publicDir,
+// followed by the sitemap index, which lists the sitemaps of every language.
+func writeRobotsTxt() error {
+	path := filepath.Join(cfg.DistDir, "robots.txt")
+	rules, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var b bytes.Buffer
+	if rules = bytes.TrimRight(rules, "\n"); len(rules) > 0 {
+		b.Write(rules)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Sitemap: " + cfg.BaseURL + "/sitemap_index.xml\n")
+	return os.WriteFile(path, b.Bytes(), 0644)
+}
+
+// collectSitemapURLs returns the home page and every listed post available in lang,
+// annotated with the URLs of all their translations.
+func collectSitemapURLs(gc *GenerationContext, lang types.Lang) []*view.SitemapURL {
+	home := &view.SitemapURL{
+		Loc: langURL(lang, "/"),
+	}
+	for _, alt := range types.SupportedLanguages {
+		home.Alternates = append(home.Alternates, view.KV{Key: alt, Value: langURL(alt, "/")})
+	}
+	home.Alternates = append(home.Alternates, view.KV{Key: "x-default", Value: cfg.BaseURL + "/"})
+
+	urls := []*view.SitemapURL{home}
+	var listed []*types.Post
+	for _, post := range sortedPosts(gc.DataStore) {
+		if post.Main.Metadata.Hidden {
+			continue
+		}
+		if _, ok := post.Translated[lang]; !ok {
+			continue
+		}
+
+		u := &view.SitemapURL{
+			Loc:     langURL(lang, post.Path),
+			LastMod: post.UpdatedAt,
+			Images:  []string{ogImageURL(post, lang)},
+		}
+		for _, alt := range postLanguages(post) {
+			u.Alternates = append(u.Alternates, view.KV{Key: alt, Value: langURL(alt, post.Path)})
+		}
+		u.Alternates = append(u.Alternates, view.KV{Key: "x-default", Value: postDefaultURL(post)})
+		urls = append(urls, u)
+		listed = append(listed, post)
+	}
+	home.LastMod = lastUpdate(listed)
+
+	sort.SliceStable(urls[1:], func(i, j int) bool {
+		return urls[1+i].Loc < urls[1+j].Loc
+	})
+	return urls
+}
+
+// encodeSitemaps renders urls into as many sitemap files as needed to stay
+// within the protocol limits.
+func encodeSitemaps(urls []*view.SitemapURL) ([][]byte, error) {
+	var files [][]byte
+	for len(urls) > 0 {
+		n := min(len(urls), sitemapMaxURLs)
+		for {
+			data, err := encodeSitemap(urls[:n])
+			if err != nil {
+				return nil, err
+			}
+			if len(data) <= sitemapMaxBytes || n == 1 {
+				files = append(files, data)
+				urls = urls[n:]
+				break
+			}
+			n /= 2
+		}
+	}
+	return files, nil
+}
+
+func encodeSitemap(urls []*view.SitemapURL) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	err := view.Sitemap(urls).Render(context.Background(), &b)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+
+	"gosuda.org/website/internal/jsonld"
+	"gosuda.org/website/internal/ogimage"
+	"gosuda.org/website/internal/types"
+)
+
+// structuredSite returns the organization publishing the site, described in lang.
+func structuredSite(lang types.Lang) *jsonld.Site {
+	return &jsonld.Site{
+		Name:        cfg.Site.Name,
+		URL:         cfg.BaseURL + "/",
+		Description: cfg.Site.Localized(lang).Description,
+		Logo:        absoluteURL(cfg.Site.Logo),
+		SameAs:      cfg.Site.SameAs,
+	}
+}
+
+// indexStructuredData returns the JSON-LD graph of the home page in lang.
+func indexStructuredData(lang types.Lang) (*jsonld.Graph, error) {
+	g := jsonld.IndexGraph(structuredSite(lang), langURL(lang, "/"), lang)
+	return g, g.Validate()
+}
+
+// postStructuredData returns the JSON-LD graph of the post page in lang. It fails if
+// the graph is invalid, e.g. when the title is too long to be a headline.
+func postStructuredData(gc *GenerationContext, post *types.Post, lang types.Lang, doc *types.Document) (*jsonld.Graph, error) {
+	url := langURL(lang, post.Path)
+	main := post.Main.Metadata
+
+	a := &jsonld.Article{
+		URL:         url,
+		Headline:    structuredHeadline(doc.Metadata.Title),
+		Description: doc.Metadata.Description,
+		Language:    lang,
+		Published:   post.CreatedAt,
+		Modified:    post.UpdatedAt,
+		Keywords:    doc.Metadata.Tags,
+		WordCount:   doc.Stats.Words,
+		Image:       ogImageURL(post, lang),
+		ImageWidth:  ogimage.Width,
+		ImageHeight: ogimage.Height,
+		GoPackage:   main.GoPackage,
+		GoRepoURL:   main.GoRepoURL,
+		Breadcrumbs: []jsonld.Crumb{
+			{Name: cfg.Site.Name, URL: langURL(lang, "/")},
+			{Name: doc.Metadata.Title, URL: url},
+		},
+	}
+
+	for _, name := range main.AuthorNames() {
+		a.Authors = append(a.Authors, authorPerson(gc, name, lang))
+	}
+
+	if lang != main.Language {
+		a.TranslationOf = langURL(main.Language, post.Path)
+	} else {
+		for _, other := range postLanguages(post) {
+			if other != lang {
+				a.Translations = append(a.Translations, langURL(other, post.Path))
+			}
+		}
+	}
+
+	g := jsonld.PostGraph(structuredSite(lang), a)
+	return g, g.Validate()
+}
+
+// structuredHeadline shortens a title that is too long to be a headline, cutting it at
+// a space in its second half if it has one, and ends it with an ellipsis.
+func structuredHeadline(title string) string {
+	runes := []rune(title)
+	if len(runes) <= jsonld.MaxHeadline {
+		return title
+	}
+	cut := string(runes[:jsonld.MaxHeadline-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.:;-") + "…"
+}
@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
+	"gosuda.org/website/internal/config"
 	"gosuda.org/website/internal/evaluate"
+	"gosuda.org/website/internal/i18n"
 	"gosuda.org/website/internal/markdown"
 	"gosuda.org/website/internal/translate"
 	"gosuda.org/website/internal/types"
@@ -84,13 +87,17 @@ var ErrLowQualityTranslation = errors.New("low quality translation")
 
 func translateAndEvaluate(ctx context.Context, post *types.Post, lang types.Lang, fullLangName string, fieldName string, text string) (string, error) {
 	log.Debug().Str("path", post.FilePath).Str("lang", string(lang)).Msgf("translating post %s", fieldName)
-	translatedText, err := translate.Translate(ctx, llmModel, text, fullLangName)
+	translatedText, err := translate.Translate(ctx, llmModel(config.TaskTranslation), text, fullLangName)
 	if err != nil {
 		return "", err
 	}
 	log.Debug().Str("path", post.FilePath).Str("lang", string(lang)).Str(fieldName, translatedText).Msgf("translated post %s", fieldName)
+	err = translate.CheckStructure(text, translatedText)
+	if err != nil {
+		return "", err
+	}
 	log.Debug().Str("path", post.FilePath).Str("lang", string(lang)).Msgf("evaluating translated %s", fieldName)
-	score, err := evaluate.EvaluateTranslation(ctx, llmModel, post.Main.Metadata.Language, lang, text, translatedText)
+	score, err := evaluate.EvaluateTranslation(ctx, llmModel(config.TaskEvaluation), post.Main.Metadata.Language, lang, text, translatedText)
 	if err != nil {
 		return "", err
 	}
@@ -103,9 +110,7 @@ func translateAndEvaluate(ctx context.Context, post *types.Post, lang types.Lang
 
 func translateLang(ctx context.Context, post *types.Post, lang types.Lang) error {
 	log.Debug().Str("path", post.FilePath).Str("lang", string(lang)).Msg("translating post")
-	original := post.Main.Markdown
-	original = strings.TrimPrefix(original, "---\n")
-	_, origDocument, ok := strings.Cut(original, "---\n")
+	origDocument, ok := stripFrontMatter(post.Main.Markdown)
 	if !ok {
 		return ErrInvalidMarkdown
 	}
@@ -138,7 +143,7 @@ func translateLang(ctx context.Context, post *types.Post, lang types.Lang) error
 	}
 	newDocument := "---\n" + string(newMeta) + "---\n" + tranDocument
 
-	doc, err := markdown.ParseMarkdown(newDocument)
+	doc, err := markdown.ParseMarkdown(newDocument, markdown.WithHeadingIDs(markdown.HeadingIDs(post.Main.TOC)))
 	if err != nil {
 		return err
 	}
@@ -146,3 +151,59 @@ func translateLang(ctx context.Context, post *types.Post, lang types.Lang) error
 
 	return nil
 }
+
+// translateMessages fills the messages missing from the catalogs of the user interface
+// with machine translations. Translations are cached in the data store and made again
+// when their English message changes; without an LLM, only cached translations are used.
+func translateMessages(gc *GenerationContext) {
+	log.Debug().Msg("start translating messages")
+	if gc.DataStore.Messages == nil {
+		gc.DataStore.Messages = make(map[types.Lang]map[string]*types.Message)
+	}
+
+	ctx := context.Background()
+	for _, lang := range types.SupportedLanguages {
+		if lang == types.LangEnglish {
+			continue
+		}
+
+		// Only the translations of messages still missing are kept.
+		translated := make(map[string]*types.Message)
+		for _, key := range gc.Messages.Missing(lang) {
+			source := gc.Messages.Source(key)
+			m := gc.DataStore.Messages[lang][key]
+			if m == nil || m.Source != source {
+				if llmRoutes == nil {
+					continue
+				}
+				text, err := translateMessage(ctx, lang, source)
+				if err != nil {
+					log.Error().Err(err).Str("lang", lang).Str("key", key).Msg("failed to translate message")
+					continue
+				}
+				m = &types.Message{Source: source, Text: text}
+			}
+			gc.Messages.Set(lang, key, m.Text)
+			translated[key] = m
+		}
+
+		if len(translated) > 0 {
+			gc.DataStore.Messages[lang] = translated
+		} else {
+			delete(gc.DataStore.Messages, lang)
+		}
+	}
+	log.Debug().Msg("done translating messages")
+}
+
+func translateMessage(ctx context.Context, lang types.Lang, source string) (string, error) {
+	text, err := translate.Translate(ctx, llmModel(config.TaskTranslation), source, types.FullLangName(lang))
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || !i18n.SamePlaceholders(source, text) {
+		return "", fmt.Errorf("%w: %q", ErrLowQualityTranslation, text)
+	}
+	return text, nil
+}
@@ -3,17 +3,26 @@ package main
 import (
 	"fmt"
 
+	"gosuda.org/website/internal/authors"
+	"gosuda.org/website/internal/config"
+	"gosuda.org/website/internal/i18n"
+	"gosuda.org/website/internal/imagemeta"
+	"gosuda.org/website/internal/ogimage"
+	"gosuda.org/website/internal/responsive"
 	"gosuda.org/website/internal/types"
 )
 
 const (
-	rootDir   = "root"
-	publicDir = "public"
-	distDir   = "dist"
-	dbFile    = "zdata/data.json.zstd"
-	baseURL   = "https://gosuda.org"
+	configFile  = "config.jsonnet"
+	publicDir   = "public"
+	authorsFile = "authors.yaml"
+	ogDir       = "og"
+	i18nDir     = "i18n"
 )
 
+// cfg is the configuration of the site, loaded from configFile before any command runs.
+var cfg *config.Config
+
 var (
 	ErrInvalidMarkdown = fmt.Errorf("invalid markdown file")
 )
@@ -22,8 +31,22 @@ type GenerationContext struct {
 	DataStore *DataStore
 	UsedPosts map[string]struct{}
 	PathMap   map[string]string
+	Authors   *authors.Registry
+	Images    *imagemeta.Cache
+	Variants  *responsive.Processor
+	// OGTemplates are the designs of Open Graph images, and Avatars loads the avatars drawn on them.
+	OGTemplates ogimage.Templates
+	Avatars     *avatarLoader
+	// Messages are the texts of the user interface.
+	Messages *i18n.Catalog
+	// Drafts renders drafts and scheduled posts, for preview builds.
+	Drafts bool
 }
 
 type DataStore struct {
 	Posts map[string]*types.Post `json:"posts"`
+	// Images caches the dimensions of the images used by posts, keyed by URL or local path.
+	Images map[string]*types.ImageInfo `json:"images,omitempty"`
+	// Messages caches the machine translations of the user interface, by language and key.
+	Messages map[types.Lang]map[string]*types.Message `json:"messages,omitempty"`
 }
@@ -6,8 +6,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pemistahl/lingua-go"
+	"gosuda.org/website/internal/markdown"
+	"gosuda.org/website/internal/types"
+	"gosuda.org/website/view"
 )
 
 func generateFileList(dir string) ([]string, error) {
@@ -72,35 +76,113 @@ func copyDir(src, dst string) error {
 	})
 }
 
+// mapDetectedLanguage returns the language of the site lingua detected, English
+// for the languages the site does not support.
 func mapDetectedLanguage(detectedLang lingua.Language) string {
-	switch detectedLang {
-	case lingua.English:
-		return "en"
-	case lingua.Spanish:
-		return "es"
-	case lingua.Chinese:
-		return "zh"
-	case lingua.Korean:
-		return "ko"
-	case lingua.Japanese:
-		return "ja"
-	case lingua.German:
-		return "de"
-	case lingua.Russian:
-		return "ru"
-	case lingua.French:
-		return "fr"
-	case lingua.Dutch:
-		return "nl"
-	case lingua.Italian:
-		return "it"
-	case lingua.Indonesian:
-		return "id"
-	case lingua.Portuguese:
-		return "pt"
-	case lingua.Swedish:
-		return "sv"
-	default:
-		return "en"
+	if lang, ok := detectedLanguages[detectedLang]; ok {
+		return lang
 	}
+	return types.LangEnglish
+}
+
+// stripFrontMatter returns the markdown body without its YAML front matter.
+func stripFrontMatter(markdown string) (string, bool) {
+	markdown = strings.TrimPrefix(markdown, "---\n")
+	_, body, ok := strings.Cut(markdown, "---\n")
+	return body, ok
+}
+
+// langURL returns the absolute URL of path in the given language.
+// English pages are served from the site root.
+func langURL(lang types.Lang, path string) string {
+	if lang == types.LangEnglish {
+		return cfg.BaseURL + path
+	}
+	return cfg.BaseURL + "/" + lang + path
+}
+
+// postCanonical returns the canonical URL of the post in the given language,
+// honoring the canonical overrides declared in the main document.
+func postCanonical(post *types.Post, lang types.Lang) string {
+	if c := post.Main.Metadata.LangCanonical[lang]; c != "" {
+		return c
+	}
+	if post.Main.Metadata.Canonical != "" {
+		return post.Main.Metadata.Canonical
+	}
+	return langURL(lang, post.Path)
+}
+
+// postLanguages returns the sorted list of languages the post is available in.
+func postLanguages(post *types.Post) []types.Lang {
+	languages := make([]types.Lang, 0, len(post.Translated))
+	for lang := range post.Translated {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// postSection returns the top-level directory under cfg.RootDir that contains the post.
+func postSection(post *types.Post) string {
+	rel, err := filepath.Rel(cfg.RootDir, post.FilePath)
+	if err != nil {
+		return ""
+	}
+	section, _, ok := strings.Cut(filepath.ToSlash(rel), "/")
+	if !ok {
+		return ""
+	}
+	return section
+}
+
+// sortedPosts returns the posts of the data store ordered by ID.
+func sortedPosts(ds *DataStore) []*types.Post {
+	posts := make([]*types.Post, 0, len(ds.Posts))
+	for _, post := range ds.Posts {
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+	return posts
+}
+
+// lastUpdate returns the newest update of posts, or the creation of the site when
+// there are none, so that the pages listing them keep their dates between builds.
+func lastUpdate(posts []*types.Post) time.Time {
+	updated := cfg.Site.CreatedAt
+	for _, post := range posts {
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+	return updated.UTC()
+}
+
+// admonitionTitles fills the titles of the admonitions of a rendered document with the
+// messages of lang.
+func admonitionTitles(html string, lang types.Lang) string {
+	return markdown.AdmonitionTitles(html, func(kind string) string {
+		return view.Message(lang, "admonition_"+kind)
+	})
+}
+
+// ogImageURL returns the absolute URL of the generated OpenGraph image of the post in lang.
+func ogImageURL(post *types.Post, lang types.Lang) string {
+	return cfg.BaseURL + "/assets/" + post.ID + "_" + lang + ".png"
+}
+
+// ogImageWebPURL returns the absolute URL of the WebP version of the OpenGraph image.
+func ogImageWebPURL(post *types.Post, lang types.Lang) string {
+	return cfg.BaseURL + "/assets/" + post.ID + "_" + lang + ".webp"
+}
+
+// postDefaultURL returns the x-default URL of the post: the unprefixed URL when
+// an English version exists, otherwise the URL of the main language version.
+func postDefaultURL(post *types.Post) string {
+	if _, ok := post.Translated[types.LangEnglish]; ok {
+		return langURL(types.LangEnglish, post.Path)
+	}
+	return langURL(post.Main.Metadata.Language, post.Path)
 }
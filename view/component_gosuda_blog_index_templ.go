@@ -42,7 +42,7 @@ func GosudaBlogIndex(m *Metadata, blogPosts []*BlogPostPreview, featuredPosts []
 			return templ_7745c5c3_Err
 		}
 		for _, post := range blogPosts {
-			templ_7745c5c3_Err = BlogPostCard(post).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = BlogPostCard(m, post).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -51,7 +51,7 @@ func GosudaBlogIndex(m *Metadata, blogPosts []*BlogPostPreview, featuredPosts []
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = BlogSidebar(featuredPosts).Render(ctx, templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = BlogSidebar(m, featuredPosts).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -59,7 +59,7 @@ func GosudaBlogIndex(m *Metadata, blogPosts []*BlogPostPreview, featuredPosts []
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = BlogFooter().Render(ctx, templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = BlogFooter(m).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,63 @@
+package view
+
+import (
+	"strconv"
+	"time"
+
+	"gosuda.org/website/internal/i18n"
+	"gosuda.org/website/internal/types"
+)
+
+// catalog holds the texts of the user interface.
+var catalog *i18n.Catalog
+
+// SetCatalog sets the message catalog templates look up their texts in.
+func SetCatalog(c *i18n.Catalog) {
+	catalog = c
+}
+
+// Message returns the message of key in lang, with its placeholders replaced by the
+// pairs of placeholder and value in oldnew. Keys are returned as is when no catalog
+// is set.
+func Message(lang types.Lang, key string, oldnew ...string) string {
+	if catalog == nil {
+		return key
+	}
+	return catalog.Message(lang, key, oldnew...)
+}
+
+// T returns the message of key in the language of the page.
+func (m *Metadata) T(key string, oldnew ...string) string {
+	return Message(m.Language, key, oldnew...)
+}
+
+// FormatDate formats date in the language of the page.
+func (m *Metadata) FormatDate(date time.Time) string {
+	return i18n.FormatDate(m.Language, date)
+}
+
+// ReadingTime formats an estimated reading time in lang.
+func ReadingTime(lang types.Lang, minutes int) string {
+	return Message(lang, "min_read", "{minutes}", strconv.Itoa(minutes))
+}
+
+// Dir returns the writing direction of the page, for the dir attribute.
+func (m *Metadata) Dir() string {
+	return string(types.Dir(m.Language))
+}
+
+// BackArrow returns the arrow pointing to the previous page in the writing direction.
+func (m *Metadata) BackArrow() string {
+	if types.Dir(m.Language) == types.RTL {
+		return "→"
+	}
+	return "←"
+}
+
+// ForwardArrow returns the arrow pointing to the next page in the writing direction.
+func (m *Metadata) ForwardArrow() string {
+	if types.Dir(m.Language) == types.RTL {
+		return "←"
+	}
+	return "→"
+}
@@ -15,15 +15,40 @@ type Metadata struct {
 	Title       string
 	Description string
 	Author      string
-	Keywords    []string
-	Image       string
-	URL         string
-	BaseURL     string
-	Canonical   string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	GoImport    string
-	CustomHead  string
+	// Authors are the authors credited on a post page; Author joins their names.
+	Authors    []*Byline
+	Keywords   []string
+	Image      string
+	URL        string
+	BaseURL    string
+	Canonical  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	GoImport   string
+	GoSource   string
+	CustomHead string
+
+	// Type is the OpenGraph object type, "website" or "article".
+	Type string
+	// SiteName is the OpenGraph site name.
+	SiteName string
+	// Locale is the OpenGraph locale of the page, and LocaleAlternates the locales of its translations.
+	Locale           string
+	LocaleAlternates []string
+	// ImageWidth, ImageHeight and ImageAlt describe Image.
+	ImageWidth  int
+	ImageHeight int
+	ImageAlt    string
+	// ImageWebP is Image encoded as WebP, offered after Image to the platforms that accept it.
+	ImageWebP string
+	// TwitterCard is the Twitter card type, e.g. "summary_large_image".
+	TwitterCard string
+
+	// Series is the series navigation of a post page, if the post is part of a series.
+	Series *SeriesNav
+
+	// StructuredData is encoded as a JSON-LD script in the page head.
+	StructuredData any
 
 	Alternate *Alternate
 }
@@ -66,13 +91,26 @@ func IndexPage(m *Metadata, blogPosts []*BlogPostPreview, featuredPosts []Featur
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.ResolveAttributeValue(m.Language)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `view/index.templ`, Line: 35, Col: 24}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `view/index.templ`, Line: 60, Col: 24}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var2)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" dir=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var3 string
+		templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.ResolveAttributeValue(m.Dir())
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `view/index.templ`, Line: 60, Col: 40}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var3)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -84,7 +122,7 @@ func IndexPage(m *Metadata, blogPosts []*BlogPostPreview, featuredPosts []Featur
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}